@@ -0,0 +1,45 @@
+package fluentbit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ageKey struct{}
+
+// WithAge returns a copy of ctx making the client store in age the Age
+// header of the responses to requests made with it, which caching proxies
+// set to how long ago, in seconds, the cached response was fetched.
+// The sample was then taken age before the request, not at request time.
+// It is left untouched for responses without a valid Age header.
+func WithAge(ctx context.Context, age *time.Duration) context.Context {
+	return context.WithValue(ctx, ageKey{}, age)
+}
+
+// recordAge stores the Age header of resp if the request context asks for it.
+func recordAge(ctx context.Context, resp *http.Response) {
+	age, ok := ctx.Value(ageKey{}).(*time.Duration)
+	if !ok || age == nil {
+		return
+	}
+
+	if d, ok := parseAge(resp.Header.Get("Age")); ok {
+		*age = d
+	}
+}
+
+// parseAge parses an Age header value, a non-negative number of seconds.
+func parseAge(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	sec, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(sec) * time.Second, true
+}
@@ -0,0 +1,64 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tt := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "0", want: 0, ok: true},
+		{value: "42", want: 42 * time.Second, ok: true},
+		{value: "-1", ok: false},
+		{value: "1.5", ok: false},
+	}
+	for _, tc := range tt {
+		got, ok := parseAge(tc.value)
+		if tc.want != got || tc.ok != ok {
+			t.Fatalf("parseAge(%q): want %s, %v; got %s, %v", tc.value, tc.want, tc.ok, got, ok)
+		}
+	}
+}
+
+func TestScraper_age(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cached") != "" {
+			w.Header().Set("Age", "30")
+		}
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1}}}`)
+	}))
+	defer srv.Close()
+
+	fresh := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL}
+	cached := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL, QueryParams: url.Values{"cached": {"1"}}}
+
+	before := time.Now()
+	results, err := NewScraper([]*Client{fresh, cached}, ScraperOptions{}).ScrapeOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := results[0].Age; got != 0 {
+		t.Fatalf("want no age without header; got %s", got)
+	}
+	if results[0].Time.Before(before) {
+		t.Fatalf("want time of fresh sample unchanged; got %s before %s", results[0].Time, before)
+	}
+
+	if want, got := 30*time.Second, results[1].Age; want != got {
+		t.Fatalf("want age %s; got %s", want, got)
+	}
+	if want, got := before.Add(-30*time.Second), results[1].Time; got.Before(want.Add(-time.Second)) || got.After(time.Now().Add(-30*time.Second)) {
+		t.Fatalf("want cached sample time around %s; got %s", want, got)
+	}
+}
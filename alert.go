@@ -0,0 +1,208 @@
+package fluentbit
+
+import (
+	"context"
+	"time"
+)
+
+// AlertCondition evaluates a rule against two successive snapshots.
+// storage is the zero value unless AlerterOptions.Storage is set.
+type AlertCondition func(prev, curr TimedMetrics, storage StorageMetrics) bool
+
+// AlertRule is a named condition watched by an Alerter.
+type AlertRule struct {
+	Name      string
+	Condition AlertCondition
+}
+
+// AlertEvent is a rule going from OK to firing or back.
+type AlertEvent struct {
+	Rule string
+	// Firing is false when the rule resolved.
+	Firing bool
+	// Time is the time of the snapshot that caused the transition.
+	Time time.Time
+}
+
+// AlertOutputErrorRate holds when any output fails more than rate
+// errors per second between the snapshots. Reset counters don't count.
+func AlertOutputErrorRate(rate float64) AlertCondition {
+	return func(prev, curr TimedMetrics, _ StorageMetrics) bool {
+		rates := Rate(prev.Metrics, curr.Metrics, curr.Time.Sub(prev.Time))
+		for _, r := range rates.Output {
+			if r.Errors > rate {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AlertInputsOverlimit holds when any input is over its mem_buf_limit.
+// It needs AlerterOptions.Storage.
+func AlertInputsOverlimit() AlertCondition {
+	return func(_, _ TimedMetrics, storage StorageMetrics) bool {
+		return len(storage.PausedInputs()) != 0
+	}
+}
+
+// AlertPipelineStalled holds when PipelineStalled does.
+func AlertPipelineStalled() AlertCondition {
+	return func(prev, curr TimedMetrics, _ StorageMetrics) bool {
+		return PipelineStalled(prev.Metrics, curr.Metrics)
+	}
+}
+
+// AlertAnyOf holds when any of the conditions does.
+func AlertAnyOf(conds ...AlertCondition) AlertCondition {
+	return func(prev, curr TimedMetrics, storage StorageMetrics) bool {
+		for _, cond := range conds {
+			if cond(prev, curr, storage) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AlertAllOf holds when all the conditions do.
+func AlertAllOf(conds ...AlertCondition) AlertCondition {
+	return func(prev, curr TimedMetrics, storage StorageMetrics) bool {
+		for _, cond := range conds {
+			if !cond(prev, curr, storage) {
+				return false
+			}
+		}
+		return len(conds) != 0
+	}
+}
+
+// AlerterOptions configures an Alerter.
+type AlerterOptions struct {
+	// Debounce is how long a condition must keep holding, or keep not
+	// holding, before its rule fires, or resolves, so flapping conditions
+	// don't flood the events. Zero transitions on the first snapshot.
+	Debounce time.Duration
+	// Storage makes each scrape fetch StorageMetrics for the conditions too.
+	Storage bool
+}
+
+// Alerter evaluates rules on every snapshot of WatchMetrics
+// and emits an event each time a rule fires or resolves.
+type Alerter struct {
+	client *Client
+	rules  []AlertRule
+	opts   AlerterOptions
+	states []alertState
+}
+
+type alertState struct {
+	firing bool
+	// since is when the condition started to disagree with firing,
+	// zero if it agrees.
+	since time.Time
+}
+
+// NewAlerter returns an Alerter of the rules against c.
+// Rules start as OK.
+func NewAlerter(c *Client, rules []AlertRule, opts AlerterOptions) *Alerter {
+	return &Alerter{
+		client: c,
+		rules:  rules,
+		opts:   opts,
+		states: make([]alertState, len(rules)),
+	}
+}
+
+// Watch runs WatchMetrics with the given interval and evaluates the rules
+// on each snapshot but the first one, which has nothing to compare with.
+// Scrape errors are delivered on the error channel, and a snapshot whose
+// storage metrics can't be fetched is skipped after delivering the error.
+// Both channels are closed once ctx is done, as with WatchMetrics.
+// Watch must not be called again until they are.
+func (a *Alerter) Watch(ctx context.Context, interval time.Duration) (<-chan AlertEvent, <-chan error) {
+	events := make(chan AlertEvent)
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		send := func(err error) bool {
+			select {
+			case errs <- err:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		snapshots, watchErrs := a.client.WatchMetrics(ctx, interval)
+		var prev *TimedMetrics
+		for snapshots != nil || watchErrs != nil {
+			select {
+			case err, ok := <-watchErrs:
+				if !ok {
+					watchErrs = nil
+					continue
+				}
+				if !send(err) {
+					return
+				}
+			case curr, ok := <-snapshots:
+				if !ok {
+					snapshots = nil
+					continue
+				}
+
+				var storage StorageMetrics
+				if a.opts.Storage {
+					var err error
+					storage, err = a.client.StorageMetrics(ctx)
+					if err != nil {
+						if ctx.Err() != nil || !send(err) {
+							return
+						}
+						continue
+					}
+				}
+
+				if prev != nil {
+					for _, ev := range a.evaluate(*prev, curr, storage) {
+						select {
+						case events <- ev:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+				prev = &curr
+			}
+		}
+	}()
+	return events, errs
+}
+
+// evaluate runs the rules on a snapshot and returns the transitions.
+func (a *Alerter) evaluate(prev, curr TimedMetrics, storage StorageMetrics) []AlertEvent {
+	var out []AlertEvent
+	for i, rule := range a.rules {
+		st := &a.states[i]
+		if rule.Condition(prev, curr, storage) == st.firing {
+			st.since = time.Time{}
+			continue
+		}
+
+		if st.since.IsZero() {
+			st.since = curr.Time
+		}
+
+		if curr.Time.Sub(st.since) < a.opts.Debounce {
+			continue
+		}
+
+		st.firing = !st.firing
+		st.since = time.Time{}
+		out = append(out, AlertEvent{Rule: rule.Name, Firing: st.firing, Time: curr.Time})
+	}
+	return out
+}
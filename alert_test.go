@@ -0,0 +1,170 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestAlerter_evaluate(t *testing.T) {
+	var holds bool
+	cond := func(_, _ TimedMetrics, _ StorageMetrics) bool { return holds }
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) TimedMetrics {
+		return TimedMetrics{Time: start.Add(time.Duration(sec) * time.Second)}
+	}
+
+	t.Run("no_debounce", func(t *testing.T) {
+		a := NewAlerter(nil, []AlertRule{{Name: "test", Condition: cond}}, AlerterOptions{})
+
+		holds = true
+		got := a.evaluate(at(0), at(1), StorageMetrics{})
+		want := []AlertEvent{{Rule: "test", Firing: true, Time: at(1).Time}}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("want firing events %+v; got %+v", want, got)
+		}
+
+		if got := a.evaluate(at(1), at(2), StorageMetrics{}); len(got) != 0 {
+			t.Fatalf("want no events while firing; got %+v", got)
+		}
+
+		holds = false
+		got = a.evaluate(at(2), at(3), StorageMetrics{})
+		want = []AlertEvent{{Rule: "test", Firing: false, Time: at(3).Time}}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("want resolved events %+v; got %+v", want, got)
+		}
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		a := NewAlerter(nil, []AlertRule{{Name: "test", Condition: cond}}, AlerterOptions{Debounce: 10 * time.Second})
+
+		steps := []struct {
+			sec    int
+			holds  bool
+			events int
+		}{
+			{sec: 0, holds: true},
+			{sec: 5, holds: true},
+			// flapped: the debounce starts over.
+			{sec: 7, holds: false},
+			{sec: 8, holds: true},
+			{sec: 17, holds: true},
+			{sec: 18, holds: true, events: 1},
+			{sec: 20, holds: false},
+			{sec: 29, holds: false},
+			{sec: 30, holds: false, events: 1},
+		}
+		prev := at(-1)
+		for _, step := range steps {
+			holds = step.holds
+			curr := at(step.sec)
+			if got := a.evaluate(prev, curr, StorageMetrics{}); len(got) != step.events {
+				t.Fatalf("at %ds want %d events; got %+v", step.sec, step.events, got)
+			}
+			prev = curr
+		}
+	})
+}
+
+func TestAlertConditions(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := TimedMetrics{Time: start, Metrics: Metrics{
+		Input:  map[string]MetricInput{"cpu.0": {Records: 10}},
+		Output: map[string]MetricOutput{"es.0": {ProcRecords: 5, Errors: 1}},
+	}}
+	curr := TimedMetrics{Time: start.Add(2 * time.Second), Metrics: Metrics{
+		Input:  map[string]MetricInput{"cpu.0": {Records: 20}},
+		Output: map[string]MetricOutput{"es.0": {ProcRecords: 5, Errors: 7}},
+	}}
+	var storage StorageMetrics
+	storage.InputChunks = map[string]PluginStorage{"cpu.0": {}}
+
+	tt := []struct {
+		name string
+		cond AlertCondition
+		want bool
+	}{
+		{"error_rate_above", AlertOutputErrorRate(2), true},
+		{"error_rate_below", AlertOutputErrorRate(3), false},
+		{"overlimit", AlertInputsOverlimit(), false},
+		{"stalled", AlertPipelineStalled(), true},
+		{"any_of", AlertAnyOf(AlertInputsOverlimit(), AlertPipelineStalled()), true},
+		{"all_of", AlertAllOf(AlertInputsOverlimit(), AlertPipelineStalled()), false},
+		{"all_of_none", AlertAllOf(), false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cond(prev, curr, storage); tc.want != got {
+				t.Fatalf("want %v; got %v", tc.want, got)
+			}
+		})
+	}
+
+	var overlimit PluginStorage
+	overlimit.Status.Overlimit = true
+	storage.InputChunks["cpu.0"] = overlimit
+	if !AlertInputsOverlimit()(prev, curr, storage) {
+		t.Fatal("want overlimit")
+	}
+}
+
+func TestAlerter_Watch(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var errorsTotal int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"output":{"es.0":{"errors":%d}}}`, atomic.AddInt32(&errorsTotal, 1000))
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+	defer srv.Client().CloseIdleConnections()
+
+	alerter := NewAlerter(client, []AlertRule{
+		{Name: "es_errors", Condition: AlertOutputErrorRate(1)},
+	}, AlerterOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := alerter.Watch(ctx, 10*time.Millisecond)
+	select {
+	case ev := <-events:
+		if ev.Rule != "es_errors" || !ev.Firing {
+			t.Fatalf("want es_errors firing; got %+v", ev)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the alert to fire")
+	}
+
+	cancel()
+	for events != nil || errs != nil {
+		select {
+		case _, ok := <-events:
+			if ok {
+				continue
+			}
+			events = nil
+		case _, ok := <-errs:
+			if ok {
+				continue
+			}
+			errs = nil
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the channels to close")
+		}
+	}
+}
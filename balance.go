@@ -0,0 +1,94 @@
+package fluentbit
+
+import "time"
+
+// Balance compares the records that entered the pipeline
+// against the records the outputs processed.
+//
+// Interpreting the balance:
+//   - Records routed to several outputs are counted once per output,
+//     so Delta is only meaningful when each record matches a single output.
+//   - Filters dropping or adding records shift the balance too.
+//   - A Delta that keeps growing while the storage backlog grows
+//     means records are being buffered; growing with no backlog
+//     means records are being lost.
+//   - A negative Delta means records got duplicated, e.g. by retries.
+type Balance struct {
+	InRecords  uint64
+	OutRecords uint64
+	// Delta is InRecords minus OutRecords.
+	Delta int64
+}
+
+// Balance sums the input records and the output processed records.
+func (m Metrics) Balance() Balance {
+	var b Balance
+	for _, in := range m.Input {
+		b.InRecords += in.Records
+	}
+	for _, out := range m.Output {
+		b.OutRecords += out.ProcRecords
+	}
+	b.Delta = delta(b.OutRecords, b.InRecords)
+	return b
+}
+
+// DivergenceRate returns how fast the gap between input and output records
+// changed between two balances taken elapsed apart, in records per second.
+// A persistently positive rate means records are piling up or getting lost.
+// It returns zero for a non-positive elapsed.
+func DivergenceRate(prev, curr Balance, elapsed time.Duration) float64 {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return 0
+	}
+
+	return float64(curr.Delta-prev.Delta) / secs
+}
+
+// EstimatedOverlimitDrops is a best-effort upper bound of the records
+// lost while inputs were over their mem_buf_limit.
+// Fluent Bit exposes no such count: an overlimit input gets paused,
+// and whatever its source keeps sending meanwhile is never counted.
+// The estimate is inferred instead, and so it is zero unless an input
+// is currently overlimit. It is then the gap between the records
+// that entered the pipeline and the ones accounted for by filters
+// (dropped minus added) and outputs (processed plus dropped).
+//
+// Assumptions and limitations:
+//   - As with Balance, each record is routed to a single output.
+//   - Records still buffered are part of the gap, which makes it
+//     an upper bound: storage reports chunks, not records,
+//     so buffered records can't be told apart.
+//   - Records never ingested because of the pause are not counted at all.
+//   - Counters are cumulative, so compare estimates over time
+//     rather than reading a single one.
+func EstimatedOverlimitDrops(s StorageMetrics, m Metrics) uint64 {
+	var overlimit bool
+	for _, p := range s.InputChunks {
+		if p.Status.Overlimit {
+			overlimit = true
+			break
+		}
+	}
+	if !overlimit {
+		return 0
+	}
+
+	var in, accounted uint64
+	for _, i := range m.Input {
+		in += i.Records
+	}
+	for _, f := range m.Filter {
+		in += f.AddRecords
+		accounted += f.DropRecords
+	}
+	for _, o := range m.Output {
+		accounted += o.ProcRecords + o.DroppedRecords
+	}
+
+	if accounted >= in {
+		return 0
+	}
+	return in - accounted
+}
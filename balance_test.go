@@ -0,0 +1,76 @@
+package fluentbit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics_Balance(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 10},
+			"mem.1": {Records: 5},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 12},
+		},
+	}
+
+	want := Balance{InRecords: 15, OutRecords: 12, Delta: 3}
+	if got := mm.Balance(); want != got {
+		t.Fatalf("want balance %+v; got %+v", want, got)
+	}
+
+	if got := (Metrics{Output: map[string]MetricOutput{"stdout.0": {ProcRecords: 2}}}).Balance(); got.Delta != -2 {
+		t.Fatalf("want negative delta; got %d", got.Delta)
+	}
+}
+
+func TestDivergenceRate(t *testing.T) {
+	prev := Balance{InRecords: 10, OutRecords: 10}
+	curr := Balance{InRecords: 30, OutRecords: 20, Delta: 10}
+
+	if want, got := 5.0, DivergenceRate(prev, curr, 2*time.Second); want != got {
+		t.Fatalf("want divergence rate %v; got %v", want, got)
+	}
+
+	if want, got := 0.0, DivergenceRate(prev, curr, 0); want != got {
+		t.Fatalf("want divergence rate %v; got %v", want, got)
+	}
+}
+
+func TestEstimatedOverlimitDrops(t *testing.T) {
+	m := Metrics{
+		Input: map[string]MetricInput{
+			"tail.0": {Records: 100},
+			"cpu.1":  {Records: 20},
+		},
+		Filter: map[string]MetricFilter{
+			"grep.0":   {DropRecords: 10},
+			"modify.1": {AddRecords: 5},
+		},
+		Output: map[string]MetricOutput{
+			"http.0": {ProcRecords: 80, DroppedRecords: 5},
+		},
+	}
+
+	var s StorageMetrics
+	s.InputChunks = map[string]PluginStorage{"tail.0": {}, "cpu.1": {}}
+	if got := EstimatedOverlimitDrops(s, m); got != 0 {
+		t.Fatalf("want no drops without overlimit inputs; got %d", got)
+	}
+
+	tail := s.InputChunks["tail.0"]
+	tail.Status.Overlimit = true
+	s.InputChunks["tail.0"] = tail
+
+	// 100 + 20 + 5 in, 10 + 80 + 5 accounted for.
+	if want, got := uint64(30), EstimatedOverlimitDrops(s, m); want != got {
+		t.Fatalf("want estimated drops %d; got %d", want, got)
+	}
+
+	m.Output["http.0"] = MetricOutput{ProcRecords: 200}
+	if got := EstimatedOverlimitDrops(s, m); got != 0 {
+		t.Fatalf("want no drops when everything is accounted for; got %d", got)
+	}
+}
@@ -0,0 +1,202 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold is the number of consecutive failures
+	// that opens the circuit.
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is how long an open circuit fails fast
+	// before letting a probe request through.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// BreakerState of a BreakerClient circuit.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every request fast with ErrCircuitOpen.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe request through
+	// to find out whether the server recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOptions tunes the circuit of a BreakerClient.
+// Zero values take the package defaults.
+type BreakerOptions struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// BreakerClient wraps a Client with a circuit breaker so scrapers
+// stop hammering a Fluent Bit instance that is down.
+// After Threshold consecutive failures the circuit opens and calls fail
+// with ErrCircuitOpen without reaching the server. Once Cooldown elapses
+// a single call probes the server: success closes the circuit,
+// failure opens it again.
+//
+// Calls canceled by the caller and ErrEndpointNotFound errors,
+// which prove the server is up, don't count as failures.
+// Calls exceeding the caller deadline do.
+// A BreakerClient is safe for concurrent use.
+type BreakerClient struct {
+	Client *Client
+
+	opts BreakerOptions
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewBreakerClient returns a BreakerClient wrapping c with a closed circuit.
+func NewBreakerClient(c *Client, opts BreakerOptions) *BreakerClient {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultBreakerThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultBreakerCooldown
+	}
+
+	return &BreakerClient{
+		Client: c,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// State returns the current state of the circuit.
+// An open circuit whose cooldown elapsed reports BreakerHalfOpen.
+func (b *BreakerClient) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.opts.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *BreakerClient) BuildInfo(ctx context.Context) (BuildInfo, error) {
+	var info BuildInfo
+	err := b.call(ctx, func() (err error) {
+		info, err = b.Client.BuildInfo(ctx)
+		return err
+	})
+	return info, err
+}
+
+func (b *BreakerClient) UpTime(ctx context.Context) (UpTime, error) {
+	var up UpTime
+	err := b.call(ctx, func() (err error) {
+		up, err = b.Client.UpTime(ctx)
+		return err
+	})
+	return up, err
+}
+
+func (b *BreakerClient) Metrics(ctx context.Context) (Metrics, error) {
+	var mm Metrics
+	err := b.call(ctx, func() (err error) {
+		mm, err = b.Client.Metrics(ctx)
+		return err
+	})
+	return mm, err
+}
+
+func (b *BreakerClient) StorageMetrics(ctx context.Context) (StorageMetrics, error) {
+	var mm StorageMetrics
+	err := b.call(ctx, func() (err error) {
+		mm, err = b.Client.StorageMetrics(ctx)
+		return err
+	})
+	return mm, err
+}
+
+// call runs fn if the circuit allows it and records its outcome.
+func (b *BreakerClient) call(ctx context.Context, fn func() error) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := fn()
+	switch {
+	case err == nil, errors.Is(err, ErrEndpointNotFound):
+		b.record(true)
+	case errors.Is(ctx.Err(), context.Canceled):
+		b.release()
+	default:
+		b.record(false)
+	}
+	return err
+}
+
+func (b *BreakerClient) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.opts.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		// a probe is already in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+// release gives up a half-open probe whose outcome is unknown,
+// so the next call probes again.
+func (b *BreakerClient) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+func (b *BreakerClient) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.opts.Threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
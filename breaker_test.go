@@ -0,0 +1,100 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerClient(t *testing.T) {
+	var healthy int32
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"uptime_sec": 1, "uptime_hr": "Fluent Bit has been running:  0 day, 0 hour, 0 minute and 1 second"}`))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	b := NewBreakerClient(&Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}, BreakerOptions{Threshold: 2, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	upTime := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultHTTPRetryBackoff*2)
+		defer cancel()
+		_, err := b.UpTime(ctx)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := upTime(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("want server error; got %v", err)
+		}
+	}
+
+	if want, got := BreakerOpen, b.State(); want != got {
+		t.Fatalf("want state %s; got %s", want, got)
+	}
+
+	sent := atomic.LoadInt32(&requests)
+	if err := upTime(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("want error %v; got %v", ErrCircuitOpen, err)
+	}
+	if want, got := sent, atomic.LoadInt32(&requests); want != got {
+		t.Fatalf("want no request while open; got %d", got-want)
+	}
+
+	now = now.Add(time.Minute)
+	if want, got := BreakerHalfOpen, b.State(); want != got {
+		t.Fatalf("want state %s; got %s", want, got)
+	}
+
+	if err := upTime(); err == nil {
+		t.Fatal("want failed probe error")
+	}
+	if want, got := BreakerOpen, b.State(); want != got {
+		t.Fatalf("want state %s after failed probe; got %s", want, got)
+	}
+
+	now = now.Add(time.Minute)
+	atomic.StoreInt32(&healthy, 1)
+	if err := upTime(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := BreakerClosed, b.State(); want != got {
+		t.Fatalf("want state %s after successful probe; got %s", want, got)
+	}
+}
+
+func TestBreakerClient_canceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	b := NewBreakerClient(&Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}, BreakerOptions{Threshold: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Metrics(ctx); err == nil {
+		t.Fatal("want error")
+	}
+
+	if want, got := BreakerClosed, b.State(); want != got {
+		t.Fatalf("want state %s after canceled call; got %s", want, got)
+	}
+}
@@ -0,0 +1,65 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// flagPrefix is the prefix Fluent Bit uses for its build flags.
+const flagPrefix = "FLB_HAVE_"
+
+// UnmarshalJSON decodes a BuildInfo, normalizing absent or null flags
+// to an empty slice.
+func (b *BuildInfo) UnmarshalJSON(data []byte) error {
+	type buildInfo BuildInfo
+	var v buildInfo
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.FluentBit.Flags == nil {
+		v.FluentBit.Flags = []string{}
+	}
+
+	*b = BuildInfo(v)
+	return nil
+}
+
+// Flags returns a copy of the build flags.
+// It never returns nil.
+func (b BuildInfo) Flags() []string {
+	out := make([]string, len(b.FluentBit.Flags))
+	copy(out, b.FluentBit.Flags)
+	return out
+}
+
+// HasFlag reports whether Fluent Bit was built with the given flag.
+// The "FLB_HAVE_" prefix is optional, so "TLS" matches "FLB_HAVE_TLS".
+func (b BuildInfo) HasFlag(flag string) bool {
+	if !strings.HasPrefix(flag, flagPrefix) {
+		flag = flagPrefix + flag
+	}
+
+	for _, f := range b.FluentBit.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Version fetches GET / like BuildInfo but only decodes
+// the version string, e.g. "1.8.15", skipping the build flags.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var info struct {
+		FluentBit struct {
+			Version string `json:"version"`
+		} `json:"fluent-bit"`
+	}
+	if err := c.fetchJSON(ctx, "/", &info); err != nil {
+		return "", err
+	}
+
+	return info.FluentBit.Version, nil
+}
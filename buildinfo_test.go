@@ -0,0 +1,87 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildInfo_UnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		name    string
+		payload string
+	}{
+		{"absent", `{"fluent-bit":{"version":"1.8.0","edition":"Community"}}`},
+		{"null", `{"fluent-bit":{"version":"1.8.0","edition":"Community","flags":null}}`},
+		{"empty", `{"fluent-bit":{"version":"1.8.0","edition":"Community","flags":[]}}`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var info BuildInfo
+			if err := json.Unmarshal([]byte(tc.payload), &info); err != nil {
+				t.Fatal(err)
+			}
+
+			if info.FluentBit.Flags == nil {
+				t.Fatal("want non-nil flags")
+			}
+
+			if want, got := "1.8.0", info.FluentBit.Version; want != got {
+				t.Fatalf("want version %q; got %q", want, got)
+			}
+
+			if flags := info.Flags(); flags == nil || len(flags) != 0 {
+				t.Fatalf("want empty flags; got %v", flags)
+			}
+
+			if info.HasFlag("TLS") {
+				t.Fatal("want no flags")
+			}
+		})
+	}
+}
+
+func TestBuildInfo_HasFlag(t *testing.T) {
+	var info BuildInfo
+	info.FluentBit.Flags = []string{"FLB_HAVE_TLS", "FLB_HAVE_METRICS"}
+
+	if !info.HasFlag("FLB_HAVE_TLS") {
+		t.Fatal("want flag FLB_HAVE_TLS")
+	}
+
+	if !info.HasFlag("METRICS") {
+		t.Fatal("want flag METRICS")
+	}
+
+	if info.HasFlag("STREAM_PROCESSOR") {
+		t.Fatal("want no flag STREAM_PROCESSOR")
+	}
+
+	if (BuildInfo{}).HasFlag("TLS") {
+		t.Fatal("want no flags on zero value")
+	}
+}
+
+func TestClient_Version(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fluent-bit":{"version":"1.8.15","edition":"Community","flags":["FLB_HAVE_TLS"]}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	got, err := client.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "1.8.15"; want != got {
+		t.Fatalf("want version %q; got %q", want, got)
+	}
+}
@@ -0,0 +1,290 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CapabilitiesTTL is how long a Capabilities result is reused
+// before the endpoints are probed again.
+const CapabilitiesTTL = 5 * time.Second
+
+// Capabilities reports which monitoring endpoints
+// are available on the running Fluent Bit instance.
+type Capabilities struct {
+	BuildInfo         bool // GET /
+	Metrics           bool // GET /api/v1/metrics
+	Storage           bool // GET /api/v1/storage
+	Health            bool // GET /api/v1/health
+	PrometheusMetrics bool // GET /api/v2/metrics/prometheus
+	Reload            bool // GET /api/v2/reload
+}
+
+type capabilitiesCache struct {
+	mu   sync.Mutex
+	caps Capabilities
+	at   time.Time
+	// stale lists the endpoints to probe again
+	// on the next call despite a fresh cache.
+	stale []string
+	// probing is closed once the ongoing probe, if any, is done,
+	// so that concurrent calls wait for it instead of probing too.
+	probing chan struct{}
+	// gen is bumped by Invalidate, telling an ongoing probe
+	// not to cache its result.
+	gen int
+}
+
+// capabilityProbe maps a probed endpoint to its Capabilities flag.
+type capabilityProbe struct {
+	endpoint string
+	ok       *bool
+}
+
+// capabilityProbes returns the probes filling caps.
+func capabilityProbes(caps *Capabilities) []capabilityProbe {
+	return []capabilityProbe{
+		{"/", &caps.BuildInfo},
+		{"/api/v1/metrics", &caps.Metrics},
+		{"/api/v1/storage", &caps.Storage},
+		{"/api/v1/health", &caps.Health},
+		{"/api/v2/metrics/prometheus", &caps.PrometheusMetrics},
+		{"/api/v2/reload", &caps.Reload},
+	}
+}
+
+// Capabilities probes the known endpoints concurrently and reports
+// which ones are available. An endpoint responding with 404 is
+// considered unavailable.
+// Failed probes are reported together as ScrapeErrors.
+// Results are cached for CapabilitiesTTL, or CapabilityCacheTTL if set,
+// unless invalidated earlier with Invalidate or InvalidateEndpoint.
+// Concurrent calls share a single probe, waiting for it
+// until their own ctx is done.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	var fresh bool
+	for {
+		c.capabilities.mu.Lock()
+		fresh = !c.capabilities.at.IsZero() && time.Since(c.capabilities.at) < c.capabilitiesTTL()
+		if fresh && len(c.capabilities.stale) == 0 {
+			caps := c.capabilities.caps
+			c.capabilities.mu.Unlock()
+			return caps, nil
+		}
+
+		probing := c.capabilities.probing
+		if probing == nil {
+			break
+		}
+		c.capabilities.mu.Unlock()
+
+		// check the cache again once the ongoing probe is done,
+		// probing ourselves if it failed.
+		select {
+		case <-probing:
+		case <-ctx.Done():
+			return Capabilities{}, ctx.Err()
+		}
+	}
+
+	// the lock is still held from the loop above.
+	done := make(chan struct{})
+	c.capabilities.probing = done
+	gen := c.capabilities.gen
+	// endpoints invalidated during the probe are kept apart
+	// to be probed again on the next call.
+	stale := c.capabilities.stale
+	c.capabilities.stale = nil
+
+	var caps Capabilities
+	probes := capabilityProbes(&caps)
+	if fresh {
+		// only probe the invalidated endpoints again.
+		caps = c.capabilities.caps
+		all := probes
+		probes = nil
+		for _, p := range all {
+			if containsString(stale, p.endpoint) {
+				probes = append(probes, p)
+			}
+		}
+	}
+	c.capabilities.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(probes))
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, endpoint string, ok *bool) {
+			defer wg.Done()
+			*ok, errs[i] = c.probe(ctx, endpoint)
+		}(i, p.endpoint, p.ok)
+	}
+	wg.Wait()
+
+	var failed ScrapeErrors
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	c.capabilities.mu.Lock()
+	defer c.capabilities.mu.Unlock()
+	c.capabilities.probing = nil
+	close(done)
+
+	// after Invalidate, the result may predate what it invalidated.
+	invalidated := c.capabilities.gen != gen
+	if err := failed.errOrNil(); err != nil {
+		if !invalidated {
+			for _, endpoint := range stale {
+				if !containsString(c.capabilities.stale, endpoint) {
+					c.capabilities.stale = append(c.capabilities.stale, endpoint)
+				}
+			}
+		}
+		return Capabilities{}, err
+	}
+
+	if !invalidated {
+		c.capabilities.caps = caps
+		if !fresh {
+			c.capabilities.at = time.Now()
+		}
+	}
+	return caps, nil
+}
+
+// Invalidate clears the cached Capabilities, so the next call probes
+// every endpoint again, e.g. right after triggering a config reload.
+// The MinVersion check runs again too, as an upgrade may have happened.
+// It is safe to call concurrently with Capabilities: the result of
+// an ongoing probe is then not cached and the next call probes again.
+func (c *Client) Invalidate() {
+	c.capabilities.mu.Lock()
+	c.capabilities.at = time.Time{}
+	c.capabilities.stale = nil
+	c.capabilities.gen++
+	c.capabilities.mu.Unlock()
+
+	c.versionCheck.mu.Lock()
+	c.versionCheck.done = false
+	c.versionCheck.mu.Unlock()
+}
+
+// InvalidateEndpoint is like Invalidate for a single endpoint,
+// e.g. "/api/v1/storage": only that one is probed again on the next call.
+// Endpoints not covered by Capabilities are ignored.
+func (c *Client) InvalidateEndpoint(endpoint string) {
+	c.capabilities.mu.Lock()
+	defer c.capabilities.mu.Unlock()
+
+	for _, p := range capabilityProbes(&Capabilities{}) {
+		if p.endpoint == endpoint && !containsString(c.capabilities.stale, endpoint) {
+			c.capabilities.stale = append(c.capabilities.stale, endpoint)
+		}
+	}
+}
+
+func (c *Client) capabilitiesTTL() time.Duration {
+	if c.CapabilityCacheTTL > 0 {
+		return c.CapabilityCacheTTL
+	}
+	return CapabilitiesTTL
+}
+
+// supports reports whether endpoint is worth requesting.
+// With TargetVersion, it is whether that version has the endpoint.
+// Without CapabilityCache, or when capabilities can't be probed,
+// or for endpoints not covered by Capabilities, it is always true
+// and the request itself reports any failure.
+func (c *Client) supports(ctx context.Context, endpoint string) bool {
+	if c.TargetVersion != "" {
+		return versionSupports(c.TargetVersion, endpoint)
+	}
+
+	if !c.CapabilityCache {
+		return true
+	}
+
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return true
+	}
+
+	switch endpoint {
+	case "/":
+		return caps.BuildInfo
+	case "/api/v1/metrics":
+		return caps.Metrics
+	case "/api/v1/storage":
+		return caps.Storage
+	case "/api/v1/health":
+		return caps.Health
+	case "/api/v2/metrics/prometheus":
+		return caps.PrometheusMetrics
+	case "/api/v2/reload":
+		return caps.Reload
+	}
+	return true
+}
+
+// probe sends a single request to endpoint without retrying
+// and reports whether it exists. The request is built like the ones
+// of fetch, so RequestBuilder applies.
+func (c *Client) probe(ctx context.Context, endpoint string) (bool, error) {
+	req, err := c.newRequest(ctx, endpoint)
+	if err != nil {
+		return false, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		if errors.Is(err, ErrRedirectDisabled) {
+			return false, &EndpointError{Endpoint: endpoint, Err: err}
+		}
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("could not probe %s: %w", endpoint, err)
+		}
+		if isDialError(err) {
+			return false, &EndpointError{Endpoint: endpoint, Err: unreachableError(err)}
+		}
+		return false, &EndpointError{Endpoint: endpoint, Err: err}
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false, fmt.Errorf("probe %s %w", endpoint, statusError(resp.StatusCode))
+	}
+
+	return true, nil
+}
+
+// CheckEndpoint sends a single request to endpoint and tells apart
+// a server that can't be reached (ErrServerUnreachable) from
+// a reachable server missing the endpoint (ErrEndpointNotFound).
+// Use Hint to turn the error into a remediation hint.
+func (c *Client) CheckEndpoint(ctx context.Context, endpoint string) error {
+	ok, err := c.probe(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return &EndpointError{Endpoint: endpoint, Err: ErrEndpointNotFound}
+	}
+
+	return nil
+}
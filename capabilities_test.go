@@ -0,0 +1,250 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/", "/api/v1/metrics", "/api/v1/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	got, err := client.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Capabilities{
+		BuildInfo: true,
+		Metrics:   true,
+		Health:    true,
+	}
+	if want != got {
+		t.Fatalf("want capabilities %+v; got %+v", want, got)
+	}
+
+	if _, err := client.Capabilities(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := int32(6), atomic.LoadInt32(&requests); want != got {
+		t.Fatalf("want %d probe requests with cache; got %d", want, got)
+	}
+}
+
+func TestClient_Capabilities_unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	_, err := client.Capabilities(context.Background())
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Fatalf("want error %v; got %v", ErrServerUnreachable, err)
+	}
+
+	var errs ScrapeErrors
+	if !errors.As(err, &errs) || len(errs) != 6 {
+		t.Fatalf("want an error per probe; got %v", err)
+	}
+}
+
+func TestClient_Capabilities_requestBuilder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+		RequestBuilder: func(ctx context.Context, url string) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer test")
+			return req, nil
+		},
+	}
+
+	got, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.BuildInfo || !got.Metrics || !got.Reload {
+		t.Fatalf("want every endpoint available; got %+v", got)
+	}
+}
+
+func TestClient_Capabilities_concurrent(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.Capabilities(context.Background())
+		}(i)
+	}
+
+	// a caller giving up doesn't wait for the ongoing probe.
+	for atomic.LoadInt32(&requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Capabilities(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want error %v; got %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want, got := int32(6), atomic.LoadInt32(&requests); want != got {
+		t.Fatalf("want %d probe requests shared by concurrent calls; got %d", want, got)
+	}
+}
+
+func TestClient_CapabilityCache(t *testing.T) {
+	var storageRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/storage" {
+			atomic.AddInt32(&storageRequests, 1)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:      srv.Client(),
+		BaseURL:         srv.URL,
+		CapabilityCache: true,
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := client.StorageMetrics(ctx)
+		if !errors.Is(err, ErrEndpointNotFound) {
+			t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+		}
+	}
+
+	// only the capabilities probe reaches the storage endpoint.
+	if want, got := int32(1), atomic.LoadInt32(&storageRequests); want != got {
+		t.Fatalf("want %d storage requests; got %d", want, got)
+	}
+}
+
+func TestClient_Invalidate(t *testing.T) {
+	var storage int32
+	requests := map[string]int{}
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		if r.URL.Path == "/api/v1/storage" && atomic.LoadInt32(&storage) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	caps, err := client.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Storage {
+		t.Fatal("want storage unavailable")
+	}
+
+	// e.g. after a reload enabling storage.metrics.
+	atomic.StoreInt32(&storage, 1)
+	client.InvalidateEndpoint("/api/v1/storage")
+	client.InvalidateEndpoint("/unknown")
+
+	caps, err = client.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Storage || !caps.Metrics {
+		t.Fatalf("want storage and metrics available; got %+v", caps)
+	}
+
+	mu.Lock()
+	if want, got := 2, requests["/api/v1/storage"]; want != got {
+		t.Fatalf("want %d storage probes; got %d", want, got)
+	}
+	if want, got := 1, requests["/api/v1/metrics"]; want != got {
+		t.Fatalf("want %d metrics probes; got %d", want, got)
+	}
+	mu.Unlock()
+
+	client.Invalidate()
+	if _, err := client.Capabilities(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want, got := 2, requests["/api/v1/metrics"]; want != got {
+		t.Fatalf("want %d metrics probes; got %d", want, got)
+	}
+}
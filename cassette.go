@@ -0,0 +1,143 @@
+package fluentbit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// CassetteEndpoints are the monitoring endpoints recorded by default.
+var CassetteEndpoints = []string{
+	"/",
+	"/api/v1/uptime",
+	"/api/v1/metrics",
+	"/api/v1/storage",
+	"/api/v1/health",
+	"/api/v2/metrics/prometheus",
+}
+
+// CassetteEntry is a recorded response of a monitoring endpoint.
+// A StatusNotFound entry records an endpoint missing from the instance.
+type CassetteEntry struct {
+	Endpoint string `json:"endpoint"`
+	Status   int    `json:"status"`
+	Body     string `json:"body"`
+}
+
+// Cassette holds recorded responses of a Fluent Bit instance, to be
+// committed as testdata and replayed by Handler for deterministic tests.
+// On disk it is JSON lines, one CassetteEntry per line:
+//
+//	{"endpoint":"/api/v1/uptime","status":200,"body":"{\"uptime_sec\":1}"}
+type Cassette []CassetteEntry
+
+// ReadCassette decodes a cassette written by Cassette.Encode.
+func ReadCassette(r io.Reader) (Cassette, error) {
+	var out Cassette
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e CassetteEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("could not decode cassette line %d: %w", n, err)
+		}
+
+		out = append(out, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read cassette: %w", err)
+	}
+
+	return out, nil
+}
+
+// Encode writes the cassette as JSON lines.
+func (c Cassette) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range c {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("could not encode cassette: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordCassette fetches each endpoint once, CassetteEndpoints if none
+// are given, with the retries and timeouts of the client.
+// Endpoints the instance doesn't serve are recorded as not found.
+// Bodies are recorded after decompression.
+func (c *Client) RecordCassette(ctx context.Context, endpoints ...string) (Cassette, error) {
+	if len(endpoints) == 0 {
+		endpoints = CassetteEndpoints
+	}
+
+	out := make(Cassette, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		e := CassetteEntry{Endpoint: endpoint, Status: http.StatusOK}
+		err := c.fetch(ctx, endpoint, false, func(r io.Reader) error {
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("could not read response: %w", err)
+			}
+
+			e.Body = string(b)
+			return nil
+		})
+		if errors.Is(err, ErrEndpointNotFound) {
+			e.Status = http.StatusNotFound
+		} else if err != nil {
+			return nil, fmt.Errorf("could not record %s: %w", endpoint, err)
+		}
+
+		out = append(out, e)
+	}
+
+	return out, nil
+}
+
+// Handler replays the cassette. Entries sharing an endpoint are served
+// in order, repeating the last one, so successive scrapes see counters
+// progress. Endpoints missing from the cassette respond not found.
+func (c Cassette) Handler() http.Handler {
+	byEndpoint := map[string][]CassetteEntry{}
+	for _, e := range c {
+		byEndpoint[e.Endpoint] = append(byEndpoint[e.Endpoint], e)
+	}
+
+	var mu sync.Mutex
+	served := map[string]int{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := byEndpoint[r.URL.Path]
+		if len(entries) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		i := served[r.URL.Path]
+		if i < len(entries)-1 {
+			served[r.URL.Path]++
+		}
+		mu.Unlock()
+
+		e := entries[i]
+		if json.Valid([]byte(e.Body)) {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		}
+		w.WriteHeader(e.Status)
+		_, _ = io.WriteString(w, e.Body)
+	})
+}
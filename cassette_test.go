@@ -0,0 +1,112 @@
+package fluentbit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/uptime":
+			_, _ = w.Write([]byte(`{"uptime_sec":1,"uptime_hr":"Fluent Bit has been running:  0 day, 0 hour, 0 minute and 1 second"}`))
+		case "/api/v2/metrics/prometheus":
+			_, _ = w.Write([]byte("fluentbit_uptime 1\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	recorded, err := client.RecordCassette(ctx, "/api/v1/uptime", "/api/v1/storage", "/api/v2/metrics/prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := http.StatusNotFound, recorded[1].Status; want != got {
+		t.Fatalf("want missing endpoint status %d; got %d", want, got)
+	}
+
+	var buf bytes.Buffer
+	if err := recorded.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := ReadCassette(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(recorded, cassette) {
+		t.Fatalf("want cassette %+v; got %+v", recorded, cassette)
+	}
+
+	replay := httptest.NewServer(cassette.Handler())
+	defer replay.Close()
+
+	client.HTTPClient = replay.Client()
+	client.BaseURL = replay.URL
+
+	up, err := client.UpTime(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("want uptime %d; got %d", want, got)
+	}
+
+	samples, err := client.PrometheusMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(samples); want != got {
+		t.Fatalf("want %d samples; got %d", want, got)
+	}
+
+	caps, err := client.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.Storage {
+		t.Fatal("want storage unavailable as recorded")
+	}
+}
+
+func TestCassette_Handler_sequence(t *testing.T) {
+	cassette := Cassette{
+		{Endpoint: "/api/v1/uptime", Status: http.StatusOK, Body: `{"uptime_sec":1}`},
+		{Endpoint: "/api/v1/uptime", Status: http.StatusOK, Body: `{"uptime_sec":2}`},
+	}
+
+	srv := httptest.NewServer(cassette.Handler())
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	for _, want := range []uint64{1, 2, 2} {
+		up, err := client.UpTime(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := up.UpTimeSec; want != got {
+			t.Fatalf("want uptime %d; got %d", want, got)
+		}
+	}
+}
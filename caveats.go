@@ -0,0 +1,70 @@
+package fluentbit
+
+import (
+	"fmt"
+
+	semver "github.com/hashicorp/go-version"
+)
+
+// fieldAvailability lists the fields that not every Fluent Bit
+// version or build reports. A field missing from the payload
+// decodes as zero, which is indistinguishable from a real zero.
+var fieldAvailability = []struct {
+	field string
+	// since is the first version reporting the field, if version dependent.
+	since string
+	// flag is the build flag the field depends on, if build dependent.
+	flag string
+}{
+	{field: "output.dropped_records", since: "1.9.0"},
+	{field: "output.retried_records", since: "1.9.0"},
+	{field: "hot_reload_count", since: "2.1.0"},
+}
+
+// FieldCaveats lists the fields that the running Fluent Bit doesn't report
+// according to its version and build flags, so their zero values
+// must not be taken as real ones, e.g.
+// "output.dropped_records: not reported before v1.9.0".
+// An unparseable version yields a single caveat saying so.
+func (b BuildInfo) FieldCaveats() []string {
+	v, err := semver.NewVersion(b.FluentBit.Version)
+	if err != nil {
+		return []string{fmt.Sprintf("unknown version %q: field availability can't be checked", b.FluentBit.Version)}
+	}
+
+	var out []string
+	for _, f := range fieldAvailability {
+		if f.since != "" && v.LessThan(semver.Must(semver.NewVersion(f.since))) {
+			out = append(out, fmt.Sprintf("%s: not reported before v%s", f.field, f.since))
+		}
+		if f.flag != "" && !b.HasFlag(f.flag) {
+			out = append(out, fmt.Sprintf("%s: not reported without %s", f.field, flagPrefix+f.flag))
+		}
+	}
+	return out
+}
+
+// endpointAvailability lists the first version serving each
+// version dependent endpoint.
+var endpointAvailability = map[string]string{
+	"/api/v1/health":             "1.8.0",
+	"/api/v2/metrics":            "1.9.0",
+	"/api/v2/metrics/prometheus": "1.9.0",
+	"/api/v2/reload":             "2.1.0",
+}
+
+// versionSupports reports whether the given Fluent Bit version serves endpoint.
+// Endpoints not version dependent and unparseable versions are always supported.
+func versionSupports(version, endpoint string) bool {
+	since, ok := endpointAvailability[endpoint]
+	if !ok {
+		return true
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return true
+	}
+
+	return !v.LessThan(semver.Must(semver.NewVersion(since)))
+}
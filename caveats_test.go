@@ -0,0 +1,102 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildInfo_FieldCaveats(t *testing.T) {
+	newBuildInfo := func(v string, flags ...string) BuildInfo {
+		var b BuildInfo
+		b.FluentBit.Version = v
+		b.FluentBit.Flags = flags
+		return b
+	}
+
+	tt := []struct {
+		name string
+		in   BuildInfo
+		want []string
+	}{
+		{"v1.8", newBuildInfo("1.8.15", "FLB_HAVE_STREAM_PROCESSOR"), []string{
+			"output.dropped_records: not reported before v1.9.0",
+			"output.retried_records: not reported before v1.9.0",
+			"hot_reload_count: not reported before v2.1.0",
+		}},
+		{"v1.9", newBuildInfo("1.9.0", "FLB_HAVE_STREAM_PROCESSOR"), []string{
+			"hot_reload_count: not reported before v2.1.0",
+		}},
+		{"v2.0", newBuildInfo("2.0.14", "FLB_HAVE_STREAM_PROCESSOR"), []string{
+			"hot_reload_count: not reported before v2.1.0",
+		}},
+		{"v2", newBuildInfo("2.1.8", "FLB_HAVE_STREAM_PROCESSOR"), nil},
+		{"unknown", newBuildInfo("master"), []string{
+			`unknown version "master": field availability can't be checked`,
+		}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.FieldCaveats(); !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want caveats %q; got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_TargetVersion(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/api/v1/metrics":
+			fmt.Fprint(w, `{"input":{},"filter":{},"output":{}}`)
+		case "/api/v2/reload":
+			fmt.Fprint(w, `{"hot_reload_count":1}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:      srv.Client(),
+		BaseURL:         srv.URL,
+		TargetVersion:   "1.8",
+		CapabilityCache: true,
+	}
+
+	ctx := context.Background()
+	if _, err := client.HotReload(ctx); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+	if _, err := client.PrometheusMetrics(ctx); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+	if want, got := int32(0), atomic.LoadInt32(&requests); want != got {
+		t.Fatalf("want %d requests; got %d", want, got)
+	}
+
+	if _, err := client.Metrics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := int32(1), atomic.LoadInt32(&requests); want != got {
+		t.Fatalf("want %d requests without probes; got %d", want, got)
+	}
+
+	// hot reload came with v2.1.0.
+	client.TargetVersion = "2.0.9"
+	if _, err := client.HotReload(ctx); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+
+	client.TargetVersion = "2.1.0"
+	if _, err := client.HotReload(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultCheckWindow is how long CheckHealth measures error rates over.
+const DefaultCheckWindow = 5 * time.Second
+
+// CheckLevel is the outcome of CheckHealth. Its value is the exit code
+// monitoring plugins, like Nagios or Icinga ones, are expected to return.
+type CheckLevel int
+
+const (
+	CheckOK CheckLevel = iota
+	CheckWarning
+	CheckCritical
+)
+
+func (l CheckLevel) String() string {
+	switch l {
+	case CheckOK:
+		return "OK"
+	case CheckWarning:
+		return "WARNING"
+	case CheckCritical:
+		return "CRITICAL"
+	default:
+		return fmt.Sprintf("CheckLevel(%d)", int(l))
+	}
+}
+
+// CheckThresholds configures CheckHealth.
+type CheckThresholds struct {
+	// WarningErrorRate and CriticalErrorRate are the output errors
+	// per second over Window above which an output
+	// is a warning or critical. Zero disables them.
+	WarningErrorRate  float64
+	CriticalErrorRate float64
+	// Window is the time between the two Metrics scrapes the error
+	// rates are measured over. Defaults to DefaultCheckWindow.
+	Window time.Duration
+	// OverlimitCritical makes overlimit inputs critical instead of a warning.
+	OverlimitCritical bool
+}
+
+// CheckResult is a monitoring check outcome: a level
+// and a one-line summary like "WARNING: input tail.0 overlimit".
+type CheckResult struct {
+	Level   CheckLevel
+	Message string
+}
+
+// CheckHealth runs a one-shot monitoring check for scripts:
+// a failing health endpoint is critical, overlimit inputs are a warning
+// unless OverlimitCritical is set, and each output is checked
+// against the error rate thresholds. Rates are measured over Window,
+// rather than averaged since start where a recent burst of errors
+// fades as uptime grows, so the check takes that long when any
+// rate threshold is set.
+// The health and storage endpoints are skipped when disabled.
+// Any other failure to fetch is returned as an error,
+// which monitoring plugins usually report as UNKNOWN.
+func (c *Client) CheckHealth(ctx context.Context, thresholds CheckThresholds) (CheckResult, error) {
+	var res CheckResult
+	var issues []string
+	report := func(level CheckLevel, format string, args ...interface{}) {
+		if level > res.Level {
+			res.Level = level
+		}
+		issues = append(issues, fmt.Sprintf(format, args...))
+	}
+
+	hs, err := c.Health(ctx)
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+	case err != nil:
+		return res, err
+	case !hs.Healthy:
+		report(CheckCritical, "health check failing")
+	}
+
+	sm, err := c.StorageMetrics(ctx)
+	switch {
+	case errors.Is(err, ErrStorageMetricsDisabled):
+	case err != nil:
+		return res, err
+	default:
+		level := CheckWarning
+		if thresholds.OverlimitCritical {
+			level = CheckCritical
+		}
+		for _, name := range sm.PausedInputs() {
+			report(level, "input %s overlimit", name)
+		}
+	}
+
+	start := time.Now()
+	mm, err := c.Metrics(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	if thresholds.WarningErrorRate <= 0 && thresholds.CriticalErrorRate <= 0 {
+		res.Message = checkMessage(res.Level, issues, mm)
+		return res, nil
+	}
+
+	window := thresholds.Window
+	if window <= 0 {
+		window = DefaultCheckWindow
+	}
+
+	timer := time.NewTimer(window)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return res, ctx.Err()
+	case <-timer.C:
+	}
+
+	prev := mm
+	now := time.Now()
+	mm, err = c.Metrics(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	rates := Rate(prev, mm, now.Sub(start))
+	for _, name := range sortedKeys(mm.Output) {
+		rate := rates.Output[name].Errors
+		switch {
+		case thresholds.CriticalErrorRate > 0 && rate > thresholds.CriticalErrorRate:
+			report(CheckCritical, "output %s errors %.2f/s", name, rate)
+		case thresholds.WarningErrorRate > 0 && rate > thresholds.WarningErrorRate:
+			report(CheckWarning, "output %s errors %.2f/s", name, rate)
+		}
+	}
+
+	res.Message = checkMessage(res.Level, issues, mm)
+	return res, nil
+}
+
+// checkMessage sums up the issues, or the pipeline size if there are none.
+func checkMessage(level CheckLevel, issues []string, mm Metrics) string {
+	if len(issues) == 0 {
+		issues = append(issues, fmt.Sprintf("%d inputs, %d outputs", len(mm.Input), len(mm.Output)))
+	}
+
+	return level.String() + ": " + strings.Join(issues, "; ")
+}
@@ -0,0 +1,123 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_CheckHealth(t *testing.T) {
+	tt := []struct {
+		name       string
+		healthy    bool
+		storage    bool
+		prevErrors int
+		errors     int
+		thresholds CheckThresholds
+		want       CheckResult
+	}{
+		{
+			name:    "ok",
+			healthy: true,
+			want:    CheckResult{Level: CheckOK, Message: "OK: 1 inputs, 1 outputs"},
+		},
+		{
+			name:       "error rate warning",
+			healthy:    true,
+			errors:     5,
+			thresholds: CheckThresholds{WarningErrorRate: 10, CriticalErrorRate: 100, Window: 100 * time.Millisecond},
+			want:       CheckResult{Level: CheckWarning, Message: "WARNING: output es.0 errors "},
+		},
+		{
+			name:       "error rate critical",
+			healthy:    true,
+			errors:     50,
+			thresholds: CheckThresholds{WarningErrorRate: 10, CriticalErrorRate: 100, Window: 100 * time.Millisecond},
+			want:       CheckResult{Level: CheckCritical, Message: "CRITICAL: output es.0 errors "},
+		},
+		{
+			// prevErrors are the errors of the first scrape, from before
+			// the window, which don't count however recent.
+			name:       "past errors",
+			healthy:    true,
+			prevErrors: 50,
+			errors:     50,
+			thresholds: CheckThresholds{WarningErrorRate: 10, CriticalErrorRate: 100, Window: 100 * time.Millisecond},
+			want:       CheckResult{Level: CheckOK, Message: "OK: 1 inputs, 1 outputs"},
+		},
+		{
+			name:    "overlimit",
+			healthy: true,
+			storage: true,
+			want:    CheckResult{Level: CheckWarning, Message: "WARNING: input tail.1 overlimit"},
+		},
+		{
+			name:       "unhealthy",
+			storage:    true,
+			thresholds: CheckThresholds{OverlimitCritical: true},
+			want:       CheckResult{Level: CheckCritical, Message: "CRITICAL: health check failing; input tail.1 overlimit"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var scrapes int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/health":
+					if !tc.healthy {
+						w.WriteHeader(http.StatusInternalServerError)
+						fmt.Fprint(w, "error")
+						return
+					}
+					fmt.Fprint(w, "ok")
+				case "/api/v1/storage":
+					if !tc.storage {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					http.ServeFile(w, r, "testdata/storage_v1_8.json")
+				case "/api/v1/metrics":
+					errors := tc.errors
+					if atomic.AddInt32(&scrapes, 1) == 1 {
+						errors = tc.prevErrors
+					}
+					fmt.Fprintf(w, `{"input":{"tail.1":{"records":10}},"output":{"es.0":{"errors":%d}}}`, errors)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			client := &Client{
+				HTTPClient: srv.Client(),
+				BaseURL:    srv.URL,
+			}
+
+			got, err := client.CheckHealth(context.Background(), tc.thresholds)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// rates depend on the time the scrapes took.
+			if tc.want.Level != got.Level || !strings.HasPrefix(got.Message, tc.want.Message) {
+				t.Fatalf("want %+v; got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckLevel_String(t *testing.T) {
+	for level, want := range map[CheckLevel]string{CheckOK: "OK", CheckWarning: "WARNING", CheckCritical: "CRITICAL"} {
+		if got := level.String(); want != got {
+			t.Fatalf("want %q; got %q", want, got)
+		}
+		if int(level) > 2 {
+			t.Fatalf("want exit code of %s to be at most 2", want)
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package fluentbit
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,9 +23,156 @@ const (
 )
 
 // Client for Fluent Bit Monitoring HTTP API.
+//
+// A Client is safe for concurrent use by multiple goroutines.
+// Its exported fields must not be modified once the client is in use;
+// any internal state (counters, caches) is synchronized.
+// A Client must not be copied after first use.
 type Client struct {
+	// attempts and retries are accessed atomically and kept first
+	// so they stay 64-bit aligned on 32-bit platforms.
+	attempts uint64
+	retries  uint64
+
+	// HTTPClient sends the requests. Its Timeout, if set, bounds each
+	// attempt on its own, so a timed out attempt is retried while
+	// the call context still has time left. Since the http.Client may be
+	// shared with other callers, prefer AttemptTimeout to bound attempts
+	// and the context or DefaultTimeout to bound whole calls.
 	HTTPClient *http.Client
 	BaseURL    string
+
+	// RetryObserver, if set, is called each time a request
+	// to endpoint fails and is about to be retried.
+	// ctx carries the scrape ID, see ScrapeIDFromContext.
+	RetryObserver func(ctx context.Context, endpoint string, attempt int, err error)
+
+	// Logger, if set, receives a line for each retry and failed scrape.
+	// Lines include the scrape ID.
+	Logger Logger
+
+	// MaxResponseSize, if positive, caps the number of response body bytes read.
+	// The cap applies to the bytes actually read, not to the Content-Length header,
+	// so it also holds for chunked responses.
+	// For gzip encoded responses it applies to the decompressed bytes,
+	// so a small compressed body can't blow up into an unbounded one.
+	MaxResponseSize int64
+
+	// RequestBuilder, if set, builds the request sent to url instead of
+	// a plain GET request. It is the escape hatch to fully control
+	// the request (cookies, context values, etc.). Retries and timeouts still apply.
+	// It is called once per attempt.
+	RequestBuilder func(ctx context.Context, url string) (*http.Request, error)
+
+	// PrometheusFallback makes Metrics fall back to the Prometheus endpoint
+	// when /api/v1/metrics is not found, as happens on builds
+	// where only the Prometheus endpoint is enabled.
+	PrometheusFallback bool
+
+	// HonorRetryAfter makes 429 Too Many Requests responses, as sent by
+	// rate-limiting proxies, be retried after the duration
+	// given by their Retry-After header, bounded by the context deadline.
+	HonorRetryAfter bool
+
+	// Decoder, if set, decodes JSON responses into v instead of encoding/json.
+	// It allows plugging a faster JSON library for high frequency scraping
+	// without this package depending on it. Metrics sections shaped as
+	// arrays, see Metrics.UnmarshalJSON, are only decoded by encoding/json.
+	// MetricInput, MetricFilter and MetricOutput implement json.Unmarshaler
+	// to accept float counters, so a Decoder honoring it still hands each
+	// plugin to encoding/json and only speeds up the rest of the payload.
+	Decoder func(r io.Reader, v interface{}) error
+
+	// DefaultTimeout, if positive, bounds calls whose context has no deadline.
+	// The precedence is: the caller context deadline, then DefaultTimeout,
+	// then DefaultHTTPRetryTimeout for the methods that have one (StorageMetrics).
+	DefaultTimeout time.Duration
+
+	// RetryNotFound makes 404 responses be retried until the context is done.
+	// By default a 404 fails right away with ErrEndpointNotFound
+	// since it usually means the endpoint is unsupported or disabled
+	// rather than transiently missing.
+	RetryNotFound bool
+
+	// AttemptTimeout, if positive, bounds each individual attempt,
+	// so a hung request is abandoned and retried with a fresh one
+	// while the overall context still has time left.
+	// When HTTPClient.Timeout is also set, the shorter one wins.
+	AttemptTimeout time.Duration
+
+	// MaxInputChunks, if positive, bounds the input_chunks entries
+	// StorageMetrics decodes, streaming past the rest of them to protect
+	// memory on instances with many inputs. StorageMetrics.Truncated
+	// tells when entries were left out. Decoder is not used then.
+	MaxInputChunks int
+
+	// ResponseEnvelope, if set, is the dot separated path of the key wrapping
+	// the payload in JSON responses, for gateways that reshape them,
+	// e.g. "data" for {"data": {...}, "status": "ok"}.
+	ResponseEnvelope string
+
+	// CapabilityCache makes requests to known endpoints consult Capabilities
+	// first and fail fast with ErrEndpointNotFound, without a round trip,
+	// when the server lacks the endpoint.
+	// The tradeoff is staleness: an endpoint enabled by a hot reload
+	// keeps failing until the cached capabilities expire.
+	CapabilityCache bool
+
+	// CapabilityCacheTTL, if positive, is how long capabilities are cached
+	// instead of CapabilitiesTTL.
+	CapabilityCacheTTL time.Duration
+
+	// TrailingSlashFallback makes a 404 response be retried once with
+	// the trailing slash of the path toggled, e.g. /api/v1/metrics/
+	// instead of /api/v1/metrics, since Fluent Bit versions and proxies
+	// disagree on the canonical form. Only if both forms respond with 404
+	// the request fails with ErrEndpointNotFound. With RetryNotFound
+	// the retries alternate between both forms.
+	// It is opt-in since it doubles the requests to genuinely missing endpoints.
+	TrailingSlashFallback bool
+
+	// DisableRedirects makes redirect responses fail with ErrRedirectDisabled
+	// instead of being followed, for setups that must not send requests,
+	// and their credentials, anywhere but BaseURL.
+	// When following redirects, credentials are dropped on cross-host redirects.
+	DisableRedirects bool
+
+	// TargetVersion, if set, pins the client to the endpoints of that
+	// Fluent Bit version, e.g. "1.8", instead of detecting them:
+	// endpoints introduced later fail with ErrEndpointNotFound without
+	// a round trip. It overrides CapabilityCache, so nothing is probed.
+	// The field caveats of the pinned version are given by a BuildInfo
+	// carrying it, see BuildInfo.FieldCaveats.
+	TargetVersion string
+
+	// QueryParams, if set, are URL encoded and appended to every request,
+	// e.g. a tenant parameter required by a proxy in front of Fluent Bit.
+	// RequestBuilder receives the URL with them.
+	QueryParams url.Values
+
+	// MinVersion, if set, is the oldest Fluent Bit version accepted,
+	// e.g. "2.0.0". The first request checks it, and every request
+	// fails with ErrVersionTooOld against an older instance.
+	// Call CheckVersion to check it eagerly instead.
+	MinVersion string
+
+	capabilities capabilitiesCache
+	versionCheck versionCheck
+}
+
+// Logger is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Attempts returns the total number of HTTP requests sent by the client.
+func (c *Client) Attempts() uint64 {
+	return atomic.LoadUint64(&c.attempts)
+}
+
+// Retries returns the total number of failed HTTP requests that were retried.
+func (c *Client) Retries() uint64 {
+	return atomic.LoadUint64(&c.retries)
 }
 
 // BuildInfo payload returned by GET /
@@ -36,10 +192,20 @@ type UpTime struct {
 }
 
 // Metrics payload returned by GET /api/v1/metrics
-// Maps keyed by metric name.
+// Maps keyed by plugin instance name.
+// Fluent Bit names each instance "type.index", e.g. "cpu.0" or "stdout.1",
+// unless the plugin sets an Alias, in which case the key is the alias as is.
+// Stream processor tasks are not exposed: Fluent Bit doesn't report them
+// in /api/v1/metrics.
 type Metrics struct {
 	Input  map[string]MetricInput  `json:"input"`
+	Filter map[string]MetricFilter `json:"filter"`
 	Output map[string]MetricOutput `json:"output"`
+
+	// Upstream holds the output upstream connection counts keyed by
+	// output name, see OutputUpstream. Nil unless parsed from
+	// Prometheus samples, as the v1 JSON doesn't report them.
+	Upstream map[string]Upstream `json:"upstream,omitempty"`
 }
 
 type MetricInput struct {
@@ -47,12 +213,31 @@ type MetricInput struct {
 	Bytes   uint64 `json:"bytes"`
 }
 
+type MetricFilter struct {
+	DropRecords uint64 `json:"drop_records"`
+	AddRecords  uint64 `json:"add_records"`
+	// EmitRecords counts records the filter emitted into the pipeline
+	// as new records, e.g. with rewrite_tag or lua. Only reported
+	// by the v2 Prometheus endpoint, so always zero from the v1 JSON.
+	EmitRecords uint64 `json:"emit_records"`
+}
+
 type MetricOutput struct {
-	ProcRecords   uint64 `json:"proc_records"`
-	ProcBytes     uint64 `json:"proc_bytes"`
+	ProcRecords uint64 `json:"proc_records"`
+	ProcBytes   uint64 `json:"proc_bytes"`
+	// Errors counts failed flushes. Fluent Bit labels them only by the
+	// output "name", with no error reason label, so they can't be broken
+	// down by cause.
 	Errors        uint64 `json:"errors"`
 	Retries       uint64 `json:"retries"`
 	RetriesFailed uint64 `json:"retries_failed"`
+	// DroppedRecords counts records discarded after retries failed.
+	// Reported since Fluent Bit v1.9, zero otherwise.
+	DroppedRecords uint64 `json:"dropped_records"`
+	// RetriedRecords counts the records of every flush attempt
+	// that was scheduled for a retry, so a record retried twice counts twice.
+	// Reported since Fluent Bit v1.9, zero otherwise.
+	RetriedRecords uint64 `json:"retried_records"`
 }
 
 type PluginStorage struct {
@@ -83,6 +268,11 @@ type StorageMetrics struct {
 	} `json:"storage_layer"`
 
 	InputChunks map[string]PluginStorage `json:"input_chunks"`
+
+	// Truncated reports InputChunks holds only the first
+	// Client.MaxInputChunks entries of the payload, so sums over
+	// the inputs, like MemSize, may be incomplete.
+	Truncated bool `json:"-"`
 }
 
 func (c *Client) BuildInfo(ctx context.Context) (BuildInfo, error) {
@@ -97,46 +287,349 @@ func (c *Client) UpTime(ctx context.Context) (UpTime, error) {
 
 func (c *Client) Metrics(ctx context.Context) (Metrics, error) {
 	var mm Metrics
-	return mm, c.fetchJSON(ctx, "/api/v1/metrics", &mm)
+	if !c.PrometheusFallback {
+		return mm, c.fetchJSON(ctx, "/api/v1/metrics", &mm)
+	}
+
+	err := c.fetch(ctx, "/api/v1/metrics", false, c.decodeJSON(&mm))
+	if !errors.Is(err, ErrEndpointNotFound) {
+		return mm, err
+	}
+
+	samples, err := c.prometheusMetrics(ctx, false)
+	if err != nil {
+		return mm, err
+	}
+
+	return MetricsFromPrometheus(samples), nil
+}
+
+// MetricsRaw fetches GET /api/v1/metrics like Metrics and also returns
+// the exact body received, so fields not modeled yet can be decoded
+// without a second request. The raw bytes are post-decompression and
+// include any ResponseEnvelope. There is no Prometheus fallback.
+func (c *Client) MetricsRaw(ctx context.Context) (Metrics, json.RawMessage, error) {
+	var mm Metrics
+	var raw json.RawMessage
+	err := c.fetch(ctx, "/api/v1/metrics", c.RetryNotFound, func(r io.Reader) error {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("could not read response: %w", err)
+		}
+
+		raw = b
+		return c.decodeJSON(&mm)(bytes.NewReader(b))
+	})
+	if err != nil {
+		return Metrics{}, nil, err
+	}
+
+	return mm, raw, nil
 }
 
+// StorageMetrics fails with ErrStorageMetricsDisabled when the endpoint
+// is missing or responds empty, as happens without storage.metrics On,
+// and with ErrStorageTimeout when it doesn't respond in time.
 func (c *Client) StorageMetrics(ctx context.Context) (StorageMetrics, error) {
+	const endpoint = "/api/v1/storage"
+
 	var mm StorageMetrics
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, DefaultHTTPRetryTimeout)
+	ctxWithTimeout, cancel := c.withTimeout(ctx, DefaultHTTPRetryTimeout)
 	defer cancel()
-	return mm, c.fetchJSON(ctxWithTimeout, "/api/v1/storage", &mm)
+
+	decode := c.decodeJSON(&mm)
+	if c.MaxInputChunks > 0 {
+		decode = c.decodeStorageMetrics(&mm)
+	}
+
+	err := c.fetch(ctxWithTimeout, endpoint, c.RetryNotFound, decode)
+	switch {
+	case err == nil:
+		return mm, nil
+	case errors.Is(err, ErrEndpointNotFound), errors.Is(err, io.EOF):
+		return mm, &EndpointError{Endpoint: endpoint, Err: ErrStorageMetricsDisabled}
+	case errors.Is(err, ErrServerUnreachable):
+		return mm, err
+	case errors.Is(ctxWithTimeout.Err(), context.DeadlineExceeded):
+		return mm, &EndpointError{Endpoint: endpoint, Err: &storageTimeoutError{err: err}}
+	default:
+		return mm, err
+	}
+}
+
+// Do fetches the given endpoint and decodes its JSON response into out.
+// It is the low-level building block of the typed methods and applies
+// the same retries, timeouts and RequestBuilder.
+func (c *Client) Do(ctx context.Context, endpoint string, out interface{}) error {
+	return c.fetchJSON(ctx, endpoint, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, endpoint string) (*http.Request, error) {
+	if c.RequestBuilder != nil {
+		return c.RequestBuilder(ctx, c.url(endpoint))
+	}
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, c.url(endpoint), nil)
+}
+
+// url returns the URL of endpoint with QueryParams appended.
+func (c *Client) url(endpoint string) string {
+	u := c.BaseURL + endpoint
+	if len(c.QueryParams) == 0 {
+		return u
+	}
+
+	sep := "?"
+	if strings.Contains(u, "?") {
+		sep = "&"
+	}
+	return u + sep + c.QueryParams.Encode()
 }
 
 func (c *Client) fetchJSON(ctx context.Context, endpoint string, ptr interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+endpoint, nil)
+	return c.fetch(ctx, endpoint, c.RetryNotFound, c.decodeJSON(ptr))
+}
+
+func (c *Client) decodeJSON(ptr interface{}) func(io.Reader) error {
+	decode := c.Decoder
+	if decode == nil {
+		decode = defaultDecoder
+	}
+
+	// Metrics.UnmarshalJSON would make any Decoder
+	// honoring json.Unmarshaler fall back to encoding/json.
+	if mm, ok := ptr.(*Metrics); ok && c.Decoder != nil {
+		ptr = (*metricsJSON)(mm)
+	}
+
+	return func(r io.Reader) error {
+		if c.ResponseEnvelope != "" {
+			inner, err := unwrapEnvelope(r, c.ResponseEnvelope)
+			if err != nil {
+				return err
+			}
+			r = bytes.NewReader(inner)
+		}
+
+		err := decode(r, ptr)
+		if err != nil {
+			return fmt.Errorf("could not json unmarshal response: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// unwrapEnvelope returns the raw JSON found at the dot separated path.
+func unwrapEnvelope(r io.Reader, path string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("could not json unmarshal response envelope: %w", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("could not json unmarshal response envelope %q: %w", key, err)
+		}
+
+		var ok bool
+		raw, ok = obj[key]
+		if !ok {
+			return nil, fmt.Errorf("response envelope key %q not found", key)
+		}
+	}
+
+	return raw, nil
+}
+
+func defaultDecoder(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// newAttemptRequest creates the request of a single attempt,
+// bounded by AttemptTimeout if set.
+func (c *Client) newAttemptRequest(ctx context.Context, endpoint string) (*http.Request, context.CancelFunc, error) {
+	cancel := context.CancelFunc(func() {})
+	if c.AttemptTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.AttemptTimeout)
+	}
+
+	req, err := c.newRequest(ctx, endpoint)
 	if err != nil {
-		return fmt.Errorf("could not create request: %w", err)
+		cancel()
+		return nil, nil, err
 	}
+
+	return traceServedBy(req), cancel, nil
+}
+
+// fetch requests endpoint, retrying as needed, and hands the response body to decode.
+// Unless retryNotFound is set, a 404 response fails right away with ErrEndpointNotFound.
+func (c *Client) fetch(ctx context.Context, endpoint string, retryNotFound bool, decode func(io.Reader) error) error {
+	ctx, cancel := c.withTimeout(ctx, 0)
+	defer cancel()
+
+	if !c.supports(ctx, endpoint) {
+		return &EndpointError{Endpoint: endpoint, Err: ErrEndpointNotFound}
+	}
+
+	if err := c.ensureVersion(ctx, endpoint); err != nil {
+		return err
+	}
+
+	var scrapeID string
+	if c.RetryObserver != nil || c.Logger != nil {
+		ctx, scrapeID = ensureScrapeID(ctx)
+	}
+
 	var resp *http.Response
-	ticker := time.NewTicker(DefaultHTTPRetryBackoff)
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("timeout while trying to reach: %s", endpoint)
-		case <-ticker.C:
-			resp, err = c.HTTPClient.Do(req)
-			if err == nil && resp.StatusCode != http.StatusNotFound {
-				break loop
+	var attempts int
+	path := endpoint
+	cancelAttempt := context.CancelFunc(func() {})
+	defer func() { cancelAttempt() }()
+
+	r := retrier{target: endpoint, backoff: DefaultHTTPRetryBackoff}
+	r.onRetry = func(attempt int, err error) {
+		atomic.AddUint64(&c.retries, 1)
+		if c.Logger != nil {
+			c.Logger.Printf("fluentbit: scrape_id=%s endpoint=%s attempt=%d: retrying: %v", scrapeID, endpoint, attempt, err)
+		}
+		if c.RetryObserver != nil {
+			c.RetryObserver(ctx, endpoint, attempt, err)
+		}
+	}
+
+	err := r.run(ctx, func(ctx context.Context, attempt int) (bool, time.Duration, error) {
+		attempts = attempt
+		atomic.AddUint64(&c.attempts, 1)
+
+		// each attempt gets a fresh request since a request
+		// can't be reused once its context is done.
+		cancelAttempt()
+		var req *http.Request
+		var err error
+		req, cancelAttempt, err = c.newAttemptRequest(ctx, path)
+		if err != nil {
+			return true, 0, fmt.Errorf("could not create request: %w", err)
+		}
+
+		resp, err = c.httpClient().Do(req)
+		if errors.Is(err, ErrRedirectDisabled) {
+			return true, 0, &EndpointError{Endpoint: endpoint, Err: err}
+		}
+		if err != nil {
+			return false, 0, err
+		}
+
+		var wait time.Duration
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			fallback := c.TrailingSlashFallback && endpoint != "/"
+			if !retryNotFound && (!fallback || path != endpoint) {
+				return true, 0, &EndpointError{Endpoint: endpoint, Err: ErrEndpointNotFound}
+			}
+			if fallback {
+				path = toggleTrailingSlash(path)
 			}
+		case resp.StatusCode == http.StatusTooManyRequests && c.HonorRetryAfter:
+			resp.Body.Close()
+			wait, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		default:
+			return true, 0, nil
 		}
+
+		return false, wait, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	})
+	if err != nil {
+		if ctx.Err() != nil && c.Logger != nil {
+			c.Logger.Printf("fluentbit: scrape_id=%s endpoint=%s attempts=%d: timeout", scrapeID, endpoint, attempts)
+		}
+		return err
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+		return statusError(resp.StatusCode)
+	}
+	recordAge(ctx, resp)
+	var body io.Reader = resp.Body
+
+	// net/http only decompresses responses to the Accept-Encoding it adds itself,
+	// not to one set by RequestBuilder.
+	if !resp.Uncompressed && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not gzip decode response: %w", err)
+		}
+		defer gz.Close()
+		body = gz
 	}
-	err = json.NewDecoder(resp.Body).Decode(ptr)
+
+	var limited *io.LimitedReader
+	if c.MaxResponseSize > 0 {
+		limited = &io.LimitedReader{R: body, N: c.MaxResponseSize + 1}
+		body = limited
+	}
+
+	err = decode(body)
+	if limited != nil && limited.N == 0 {
+		return fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, c.MaxResponseSize)
+	}
+
+	return err
+}
+
+func toggleTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path + "/"
+}
+
+// withTimeout bounds ctx unless it already has a deadline.
+// The precedence is: ctx deadline > DefaultTimeout > fallback.
+// A zero fallback means no timeout.
+func (c *Client) withTimeout(ctx context.Context, fallback time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	timeout := fallback
+	if c.DefaultTimeout > 0 {
+		timeout = c.DefaultTimeout
+	}
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// parseRetryAfter parses a Retry-After header value,
+// either delay seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(v)
 	if err != nil {
-		return fmt.Errorf("could not json unmarshal response: %w", err)
+		return 0, false
 	}
 
-	return nil
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
 }
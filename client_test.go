@@ -1,13 +1,24 @@
 package fluentbit
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,21 +28,35 @@ import (
 
 var baseURL string
 
+// recordCassette, if set, is the path where TestClient_RecordCassette saves
+// the responses of the container, e.g.:
+//
+//	go test -run TestClient_RecordCassette -record-cassette testdata/cassette_v1_8.jsonl
+var recordCassette = flag.String("record-cassette", "", "path to record a cassette of the fluent bit container")
+
 var defaultTestConfig = `
 [SERVICE]
      HTTP_Server On
      HTTP_Listen 0.0.0.0
      HTTP_Port 2020
      storage.metrics On
+     Health_Check On
+     HC_Errors_Count 5
+     HC_Retry_Failure_Count 5
+     HC_Period 60
 [INPUT]
      name cpu
 [OUTPUT]
      name stdout
+[OUTPUT]
+     name  stdout
+     alias aliased_stdout
 `
 
 const (
 	version             = "1.8"
-	flushInterval       = 1
+	readyTimeout        = 30 * time.Second
+	readyPollInterval   = 100 * time.Millisecond
 	fluentBitConfigName = "fluent-bit.conf"
 )
 
@@ -124,7 +149,17 @@ func getFluentBitContainerBaseURL(pool *dockertest.Pool, container *dockertest.R
 		return "", err
 	}
 
-	time.Sleep(time.Second * flushInterval) // to be surer the server is ready.
+	client := &Client{
+		HTTPClient: http.DefaultClient,
+		BaseURL:    baseURL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	if err := client.WaitReady(ctx, readyPollInterval); err != nil {
+		return "", err
+	}
 
 	return baseURL, nil
 }
@@ -219,6 +254,10 @@ func TestClient_Metrics(t *testing.T) {
 	if want, got := 1, len(mm.Output); got < want {
 		t.Fatalf("expected outputs len to be >= %d; got %d", want, got)
 	}
+
+	if _, ok := mm.OutputByAlias("aliased_stdout"); !ok {
+		t.Fatalf("expected aliased output to be keyed by alias; got %v", mm.Output)
+	}
 }
 
 func TestClient_StorageMetrics(t *testing.T) {
@@ -240,3 +279,819 @@ func TestClient_StorageMetrics(t *testing.T) {
 		t.Fatalf("expected input chunks len to be >= %d; got %d", want, got)
 	}
 }
+
+func TestClient_RecordCassette(t *testing.T) {
+	if *recordCassette == "" {
+		t.Skip("no -record-cassette path given")
+	}
+
+	client := &Client{
+		HTTPClient: http.DefaultClient,
+		BaseURL:    baseURL,
+	}
+
+	cassette, err := client.RecordCassette(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(*recordCassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := cassette.Encode(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_RetryObserver(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1,"uptime_hr":"Fluent Bit has been running:  0 day, 0 hour, 0 minute and 1 second"}`)
+	}))
+	defer srv.Close()
+
+	var attempts []int
+	client := &Client{
+		HTTPClient:    srv.Client(),
+		BaseURL:       srv.URL,
+		RetryNotFound: true,
+		RetryObserver: func(ctx context.Context, endpoint string, attempt int, err error) {
+			if id, _ := ScrapeIDFromContext(ctx); id != "test-scrape" {
+				t.Errorf("expected scrape id %q; got %q", "test-scrape", id)
+			}
+			if want, got := "/api/v1/uptime", endpoint; want != got {
+				t.Errorf("expected endpoint %q; got %q", want, got)
+			}
+			if err == nil {
+				t.Error("expected retry error to be non-nil")
+			}
+			attempts = append(attempts, attempt)
+		},
+	}
+
+	_, err := client.UpTime(WithScrapeID(context.Background(), "test-scrape"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := []int{1, 2}, attempts; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected observed attempts to be %v; got %v", want, got)
+	}
+
+	if want, got := uint64(3), client.Attempts(); want != got {
+		t.Fatalf("expected attempts to be %d; got %d", want, got)
+	}
+
+	if want, got := uint64(2), client.Retries(); want != got {
+		t.Fatalf("expected retries to be %d; got %d", want, got)
+	}
+}
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// flushing before the body is complete forces a chunked
+		// response without a Content-Length header.
+		fmt.Fprint(w, `{"uptime_sec":1,"uptime_hr":"`)
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, strings.Repeat("x", 1024))
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, `"}`)
+	}))
+	defer srv.Close()
+
+	t.Run("exceeded", func(t *testing.T) {
+		client := &Client{
+			HTTPClient:      srv.Client(),
+			BaseURL:         srv.URL,
+			MaxResponseSize: 512,
+		}
+
+		_, err := client.UpTime(context.Background())
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("expected error %v; got %v", ErrResponseTooLarge, err)
+		}
+	})
+
+	t.Run("within", func(t *testing.T) {
+		client := &Client{
+			HTTPClient:      srv.Client(),
+			BaseURL:         srv.URL,
+			MaxResponseSize: 2048,
+		}
+
+		up, err := client.UpTime(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want, got := uint64(1), up.UpTimeSec; want != got {
+			t.Fatalf("expected uptime to be %d; got %d", want, got)
+		}
+	})
+}
+
+func TestClient_MaxResponseSize_gzip(t *testing.T) {
+	// a few KiB of gzip decompressing into a MiB.
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	fmt.Fprint(gz, `{"uptime_sec":1,"uptime_hr":"`)
+	fmt.Fprint(gz, strings.Repeat("x", 1<<20))
+	fmt.Fprint(gz, `"}`)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	tt := []struct {
+		name           string
+		acceptEncoding string
+	}{
+		{name: "transparent"},
+		{name: "explicit", acceptEncoding: "gzip"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &Client{
+				HTTPClient:      srv.Client(),
+				BaseURL:         srv.URL,
+				MaxResponseSize: int64(compressed.Len()) * 2,
+				RequestBuilder: func(ctx context.Context, url string) (*http.Request, error) {
+					req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+					if err == nil && tc.acceptEncoding != "" {
+						req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+					}
+					return req, err
+				},
+			}
+
+			_, err := client.UpTime(context.Background())
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("expected error %v; got %v", ErrResponseTooLarge, err)
+			}
+
+			client.MaxResponseSize = 2 << 20
+			up, err := client.UpTime(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want, got := uint64(1), up.UpTimeSec; want != got {
+				t.Fatalf("expected uptime to be %d; got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestClient_MetricsRaw(t *testing.T) {
+	const body = `{"input":{"cpu.0":{"records":1,"bytes":10,"unmodeled":3}},"filter":{},"output":{}}`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	fmt.Fprint(gz, body)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	mm, raw, err := client.MetricsRaw(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := body, string(raw); want != got {
+		t.Fatalf("want raw body %s; got %s", want, got)
+	}
+
+	if want, got := uint64(1), mm.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want input records %d; got %d", want, got)
+	}
+
+	var extra struct {
+		Input map[string]struct {
+			Unmodeled int `json:"unmodeled"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 3, extra.Input["cpu.0"].Unmodeled; want != got {
+		t.Fatalf("want unmodeled field %d; got %d", want, got)
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+		RequestBuilder: func(ctx context.Context, url string) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.AddCookie(&http.Cookie{Name: "session", Value: "test"})
+			return req, nil
+		},
+	}
+
+	var out struct {
+		Status string `json:"status"`
+	}
+	err := client.Do(context.Background(), "/api/v1/custom", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "ok", out.Status; want != got {
+		t.Fatalf("expected status %q; got %q", want, got)
+	}
+}
+
+func TestClient_HonorRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:      srv.Client(),
+		BaseURL:         srv.URL,
+		HonorRetryAfter: true,
+	}
+
+	start := time.Now()
+	_, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected client to wait at least 1s; waited %s", elapsed)
+	}
+
+	if want, got := int32(2), atomic.LoadInt32(&calls); want != got {
+		t.Fatalf("expected %d requests; got %d", want, got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	tt := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{now.Add(-5 * time.Second).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tc := range tt {
+		got, ok := parseRetryAfter(tc.in, now)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parseRetryAfter(%q) want %s, %v; got %s, %v", tc.in, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+// TestClient_concurrent is meant to be run with the race detector.
+func TestClient_concurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/metrics":
+			fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"output":{}}`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:    srv.Client(),
+		BaseURL:       srv.URL,
+		RetryObserver: func(context.Context, string, int, error) {},
+	}
+
+	const goroutines = 20
+	errs := make(chan error, goroutines*2)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := client.Metrics(context.Background())
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.Capabilities(context.Background())
+			errs <- err
+			_ = client.Attempts()
+			_ = client.Retries()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want, got := uint64(goroutines), client.Attempts(); got < want {
+		t.Fatalf("expected attempts to be >= %d; got %d", want, got)
+	}
+}
+
+func TestClient_Decoder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	var called bool
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+		Decoder: func(r io.Reader, v interface{}) error {
+			called = true
+			return json.NewDecoder(r).Decode(v)
+		},
+	}
+
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !called {
+		t.Fatal("expected custom decoder to be called")
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("expected uptime to be %d; got %d", want, got)
+	}
+}
+
+func TestClient_Decoder_metrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+		Decoder: func(r io.Reader, v interface{}) error {
+			// decoders honoring json.Unmarshaler would hand it back to encoding/json.
+			if _, ok := v.(json.Unmarshaler); ok {
+				return fmt.Errorf("unexpected json.Unmarshaler %T", v)
+			}
+			return json.NewDecoder(r).Decode(v)
+		},
+	}
+
+	mm, err := client.Metrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (MetricInput{Records: 1, Bytes: 10}), mm.Input["cpu.0"]; want != got {
+		t.Fatalf("expected input %+v; got %+v", want, got)
+	}
+}
+
+// BenchmarkClient_Metrics_decoder compares Decoder setups on a metrics
+// payload. Every plugin is decoded by its UnmarshalJSON whatever the
+// Decoder, so this measures the overhead around the plugins only.
+func BenchmarkClient_Metrics_decoder(b *testing.B) {
+	var payload strings.Builder
+	payload.WriteString(`{"input":{`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			payload.WriteString(",")
+		}
+		fmt.Fprintf(&payload, `"tail.%d":{"records":%d,"bytes":%d}`, i, i*100, i*1000)
+	}
+	payload.WriteString(`},"output":{`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			payload.WriteString(",")
+		}
+		fmt.Fprintf(&payload, `"forward.%d":{"proc_records":%d,"proc_bytes":%d,"errors":0,"retries":0,"retries_failed":0}`, i, i*100, i*1000)
+	}
+	payload.WriteString(`}}`)
+	body := []byte(payload.String())
+
+	decoders := []struct {
+		name   string
+		decode func(io.Reader, interface{}) error
+	}{
+		{"default", nil},
+		{"unmarshal", func(r io.Reader, v interface{}) error {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, v)
+		}},
+	}
+	for _, d := range decoders {
+		b.Run(d.name, func(b *testing.B) {
+			client := &Client{Decoder: d.decode}
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var mm Metrics
+				if err := client.decodeJSON(&mm)(bytes.NewReader(body)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_withTimeout(t *testing.T) {
+	deadlineIn := func(ctx context.Context) time.Duration {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0
+		}
+		return time.Until(deadline).Round(time.Second)
+	}
+
+	t.Run("caller_deadline", func(t *testing.T) {
+		client := &Client{DefaultTimeout: 10 * time.Second}
+		parent, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		ctx, cancel := client.withTimeout(parent, DefaultHTTPRetryTimeout)
+		defer cancel()
+		if want, got := 20*time.Second, deadlineIn(ctx); want != got {
+			t.Fatalf("expected deadline in %s; got %s", want, got)
+		}
+	})
+
+	t.Run("default_timeout", func(t *testing.T) {
+		client := &Client{DefaultTimeout: 10 * time.Second}
+		ctx, cancel := client.withTimeout(context.Background(), DefaultHTTPRetryTimeout)
+		defer cancel()
+		if want, got := 10*time.Second, deadlineIn(ctx); want != got {
+			t.Fatalf("expected deadline in %s; got %s", want, got)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		client := &Client{}
+		ctx, cancel := client.withTimeout(context.Background(), DefaultHTTPRetryTimeout)
+		defer cancel()
+		if want, got := DefaultHTTPRetryTimeout, deadlineIn(ctx); want != got {
+			t.Fatalf("expected deadline in %s; got %s", want, got)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		client := &Client{}
+		ctx, cancel := client.withTimeout(context.Background(), 0)
+		defer cancel()
+		if _, ok := ctx.Deadline(); ok {
+			t.Fatal("expected no deadline")
+		}
+	})
+}
+
+func TestClient_DefaultTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:     srv.Client(),
+		BaseURL:        srv.URL,
+		DefaultTimeout: 500 * time.Millisecond,
+		RetryNotFound:  true,
+	}
+
+	start := time.Now()
+	if _, err := client.UpTime(context.Background()); err == nil {
+		t.Fatal("expected timeout error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected default timeout to apply; took %s", elapsed)
+	}
+}
+
+func TestClient_notFound(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.StorageMetrics(ctx)
+	if !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("expected error %v; got %v", ErrEndpointNotFound, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected persistent 404 to return promptly; took %s", elapsed)
+	}
+
+	if want, got := int32(1), atomic.LoadInt32(&calls); want != got {
+		t.Fatalf("expected %d request; got %d", want, got)
+	}
+}
+
+func TestClient_TrailingSlashFallback(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v1/uptime/":
+			fmt.Fprint(w, `{"uptime_sec":1}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:            srv.Client(),
+		BaseURL:               srv.URL,
+		TrailingSlashFallback: true,
+	}
+
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("expected uptime to be %d; got %d", want, got)
+	}
+
+	if want := []string{"/api/v1/uptime", "/api/v1/uptime/"}; !reflect.DeepEqual(want, paths) {
+		t.Fatalf("expected requested paths %v; got %v", want, paths)
+	}
+
+	paths = nil
+	_, err = client.StorageMetrics(context.Background())
+	if !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("expected error %v; got %v", ErrEndpointNotFound, err)
+	}
+
+	if want := []string{"/api/v1/storage", "/api/v1/storage/"}; !reflect.DeepEqual(want, paths) {
+		t.Fatalf("expected requested paths %v; got %v", want, paths)
+	}
+}
+
+func TestClient_AttemptTimeout(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// hang until the attempt is abandoned.
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:     srv.Client(),
+		BaseURL:        srv.URL,
+		AttemptTimeout: 200 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	up, err := client.UpTime(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("expected uptime to be %d; got %d", want, got)
+	}
+
+	if want, got := int32(2), atomic.LoadInt32(&calls); want != got {
+		t.Fatalf("expected %d requests; got %d", want, got)
+	}
+}
+
+func TestClient_httpClientTimeout(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// hang until the attempt is abandoned.
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	hc := srv.Client()
+	hc.Timeout = 200 * time.Millisecond
+	client := &Client{
+		HTTPClient: hc,
+		BaseURL:    srv.URL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the http.Client timeout bounds each attempt, not the whole call.
+	up, err := client.UpTime(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("expected uptime to be %d; got %d", want, got)
+	}
+
+	if want, got := int32(2), atomic.LoadInt32(&calls); want != got {
+		t.Fatalf("expected %d requests; got %d", want, got)
+	}
+}
+
+func TestClient_ResponseEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok","data":{"result":{"uptime_sec":7,"uptime_hr":"7s"}}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:       srv.Client(),
+		BaseURL:          srv.URL,
+		ResponseEnvelope: "data.result",
+	}
+
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(7), up.UpTimeSec; want != got {
+		t.Fatalf("expected uptime to be %d; got %d", want, got)
+	}
+
+	client.ResponseEnvelope = "payload"
+	if _, err := client.UpTime(context.Background()); err == nil {
+		t.Fatal("expected error on missing envelope key")
+	}
+}
+
+func TestClient_StorageMetrics_errors(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		for _, status := range []int{http.StatusNotFound, http.StatusOK} {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// an empty 200 response stands for a disabled endpoint too.
+				w.WriteHeader(status)
+			}))
+
+			client := &Client{
+				HTTPClient: srv.Client(),
+				BaseURL:    srv.URL,
+			}
+
+			_, err := client.StorageMetrics(context.Background())
+			srv.Close()
+			if !errors.Is(err, ErrStorageMetricsDisabled) {
+				t.Fatalf("status %d: expected error %v; got %v", status, ErrStorageMetricsDisabled, err)
+			}
+			if !errors.Is(err, ErrEndpointNotFound) {
+				t.Fatalf("status %d: expected error to match %v", status, ErrEndpointNotFound)
+			}
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+
+		client := &Client{
+			HTTPClient:     srv.Client(),
+			BaseURL:        srv.URL,
+			DefaultTimeout: 300 * time.Millisecond,
+		}
+
+		_, err := client.StorageMetrics(context.Background())
+		if !errors.Is(err, ErrStorageTimeout) {
+			t.Fatalf("expected error %v; got %v", ErrStorageTimeout, err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected error to match %v", context.DeadlineExceeded)
+		}
+		if Hint(err) == "" {
+			t.Fatal("expected a hint for the storage timeout")
+		}
+		if want, got := "timeout while trying to reach", err.Error(); !strings.Contains(got, want) {
+			t.Fatalf("expected error to keep its cause %q; got %q", want, got)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		client := &Client{
+			HTTPClient:     srv.Client(),
+			BaseURL:        srv.URL,
+			DefaultTimeout: 4 * DefaultHTTPRetryBackoff,
+		}
+
+		_, err := client.StorageMetrics(context.Background())
+		if !errors.Is(err, ErrServerUnreachable) {
+			t.Fatalf("expected error %v; got %v", ErrServerUnreachable, err)
+		}
+		if errors.Is(err, ErrStorageTimeout) {
+			t.Fatalf("expected error not to match %v", ErrStorageTimeout)
+		}
+		if want, got := "HTTP_Listen", Hint(err); !strings.Contains(got, want) {
+			t.Fatalf("expected hint to contain %q; got %q", want, got)
+		}
+	})
+}
+
+func TestClient_QueryParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "acme & co", r.URL.Query().Get("tenant"); want != got {
+			t.Errorf("want tenant %q; got %q", want, got)
+		}
+		if want, got := "json", r.URL.Query().Get("format"); want != got {
+			t.Errorf("want format %q; got %q", want, got)
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:  srv.Client(),
+		BaseURL:     srv.URL,
+		QueryParams: url.Values{"tenant": {"acme & co"}, "format": {"json"}},
+	}
+
+	if _, err := client.UpTime(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := srv.URL+"/api/v1/uptime?format=json&tenant=acme+%26+co", client.url("/api/v1/uptime"); want != got {
+		t.Fatalf("want url %q; got %q", want, got)
+	}
+}
@@ -0,0 +1,101 @@
+package fluentbit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// openMetricsContentType is the content type of the OpenMetrics text format.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Collector re-exports the metrics of a Fluent Bit instance to Prometheus,
+// serving them in the OpenMetrics format on every scrape.
+//
+// Counters are exposed raw, never smoothed, so when Fluent Bit restarts
+// they drop and Prometheus rate() treats the drop as a counter reset.
+// A drop alone goes unnoticed when the counters grew back past their
+// previous value between two scrapes, so every counter also carries
+// a _created sample set to the start time of Fluent Bit, derived
+// from its uptime, which moves forward on every restart detected
+// by the uptime going down.
+//
+// Labels and Namer are used as in OpenMetricsWriter.
+type Collector struct {
+	Labels map[string]string
+	Namer  MetricNamer
+
+	client *Client
+
+	mu       sync.Mutex
+	seen     bool
+	upTime   uint64
+	start    time.Time
+	restarts uint64
+	now      func() time.Time
+}
+
+// NewCollector returns a Collector of the metrics of c.
+func NewCollector(c *Client) *Collector {
+	return &Collector{client: c, now: time.Now}
+}
+
+// Restarts returns the number of Fluent Bit restarts detected so far,
+// that is, the times the uptime went down between two scrapes.
+func (col *Collector) Restarts() uint64 {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	return col.restarts
+}
+
+// ServeHTTP scrapes Fluent Bit and writes its metrics,
+// responding with 502 if the scrape fails
+// and with 500 if the Labels are invalid.
+func (col *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ow := OpenMetricsWriter{Labels: col.Labels, Namer: col.Namer}
+	if err := ValidateLabels(ow.Labels); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mm, start, err := col.collect(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", openMetricsContentType)
+	_ = ow.write(w, mm, start)
+}
+
+// collect scrapes the metrics along with the start time of Fluent Bit.
+func (col *Collector) collect(ctx context.Context) (Metrics, time.Time, error) {
+	up, err := col.client.UpTime(ctx)
+	if err != nil {
+		return Metrics{}, time.Time{}, err
+	}
+
+	mm, err := col.client.Metrics(ctx)
+	if err != nil {
+		return Metrics{}, time.Time{}, err
+	}
+
+	col.mu.Lock()
+	defer col.mu.Unlock()
+
+	restarted := col.seen && up.UpTimeSec < col.upTime
+	if restarted {
+		col.restarts++
+	}
+
+	// the start time is only derived again on restarts, since the
+	// uptime is truncated to seconds and would make it jitter.
+	if !col.seen || restarted {
+		col.start = col.now().Add(-time.Duration(up.UpTimeSec) * time.Second).Truncate(time.Second)
+	}
+	col.seen = true
+	col.upTime = up.UpTimeSec
+
+	return mm, col.start, nil
+}
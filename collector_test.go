@@ -0,0 +1,124 @@
+package fluentbit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	upTime, records := 100, 1000
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/uptime":
+			fmt.Fprintf(w, `{"uptime_sec":%d}`, upTime)
+		case "/api/v1/metrics":
+			fmt.Fprintf(w, `{"input":{"cpu.0":{"records":%d}}}`, records)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	col := NewCollector(&Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	})
+	now := time.Unix(1600000000, 0)
+	col.now = func() time.Time { return now }
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		col.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if want, got := http.StatusOK, rec.Code; want != got {
+			t.Fatalf("want status %d; got %d", want, got)
+		}
+		if want, got := openMetricsContentType, rec.Header().Get("Content-Type"); want != got {
+			t.Fatalf("want content type %q; got %q", want, got)
+		}
+		b, err := ioutil.ReadAll(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	body := scrape()
+	for _, want := range []string{
+		`fluentbit_input_records_total{name="cpu.0"} 1000`,
+		`fluentbit_input_records_created{name="cpu.0"} 1599999900`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("want %q in:\n%s", want, body)
+		}
+	}
+
+	// the start time stays put while running.
+	now = now.Add(10*time.Second + 500*time.Millisecond)
+	upTime, records = 111, 1100
+	if want := `fluentbit_input_records_created{name="cpu.0"} 1599999900`; !strings.Contains(scrape(), want) {
+		t.Fatalf("want start time unchanged")
+	}
+
+	// restart: counters are exposed raw and the start time moves forward.
+	now = now.Add(10 * time.Second)
+	upTime, records = 5, 2000
+	body = scrape()
+	for _, want := range []string{
+		`fluentbit_input_records_total{name="cpu.0"} 2000`,
+		`fluentbit_input_records_created{name="cpu.0"} 1600000015`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("want %q in:\n%s", want, body)
+		}
+	}
+
+	if want, got := uint64(1), col.Restarts(); want != got {
+		t.Fatalf("want %d restarts; got %d", want, got)
+	}
+}
+
+func TestCollector_Labels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/uptime":
+			fmt.Fprint(w, `{"uptime_sec":100}`)
+		case "/api/v1/metrics":
+			fmt.Fprint(w, `{"input":{"cpu.0":{"records":1000}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	col := NewCollector(&Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	})
+	col.Labels = map[string]string{"cluster": "eu-1"}
+	col.Namer = func(section, plugin, field string) string {
+		return "fb_" + section + "_" + field
+	}
+
+	rec := httptest.NewRecorder()
+	col.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`fb_input_records_total{name="cpu.0",cluster="eu-1"} 1000`,
+		`fb_input_records_created{name="cpu.0",cluster="eu-1"} `,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("want %q in:\n%s", want, rec.Body)
+		}
+	}
+
+	col.Labels = map[string]string{"__reserved": "x"}
+	rec = httptest.NewRecorder()
+	col.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want, got := http.StatusInternalServerError, rec.Code; want != got {
+		t.Fatalf("want status %d for invalid labels; got %d", want, got)
+	}
+}
@@ -0,0 +1,152 @@
+package fluentbit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// UnmarshalJSON decodes a MetricInput accepting float counters,
+// see parseCounter.
+func (in *MetricInput) UnmarshalJSON(data []byte) error {
+	return decodeCounters(json.NewDecoder(bytes.NewReader(data)), in, nil)
+}
+
+func (in *MetricInput) counterFields() map[string]*uint64 {
+	return map[string]*uint64{
+		"records": &in.Records,
+		"bytes":   &in.Bytes,
+	}
+}
+
+// UnmarshalJSON decodes a MetricFilter accepting float counters,
+// see parseCounter.
+func (f *MetricFilter) UnmarshalJSON(data []byte) error {
+	return decodeCounters(json.NewDecoder(bytes.NewReader(data)), f, nil)
+}
+
+func (f *MetricFilter) counterFields() map[string]*uint64 {
+	return map[string]*uint64{
+		"drop_records": &f.DropRecords,
+		"add_records":  &f.AddRecords,
+		"emit_records": &f.EmitRecords,
+	}
+}
+
+// UnmarshalJSON decodes a MetricOutput accepting float counters,
+// see parseCounter.
+func (o *MetricOutput) UnmarshalJSON(data []byte) error {
+	return decodeCounters(json.NewDecoder(bytes.NewReader(data)), o, nil)
+}
+
+func (o *MetricOutput) counterFields() map[string]*uint64 {
+	return map[string]*uint64{
+		"proc_records":    &o.ProcRecords,
+		"proc_bytes":      &o.ProcBytes,
+		"errors":          &o.Errors,
+		"retries":         &o.Retries,
+		"retries_failed":  &o.RetriesFailed,
+		"dropped_records": &o.DroppedRecords,
+		"retried_records": &o.RetriedRecords,
+	}
+}
+
+// counters is a plugin metrics type decoded by decodeCounters.
+type counters interface {
+	// counterFields returns pointers to the counters keyed by JSON name.
+	counterFields() map[string]*uint64
+}
+
+// decodeCounters decodes the JSON object read by dec into the counters
+// of c. Absent counters are left untouched. Other keys are handed to
+// other, which decodes their value from dec, or skipped if it's nil.
+func decodeCounters(dec *json.Decoder, c counters, other func(key string) error) error {
+	fields := c.counterFields()
+	return decodeObject(dec, func(key string) error {
+		dst, ok := fields[key]
+		if !ok {
+			if other != nil {
+				return other(key)
+			}
+			return skipValue(dec)
+		}
+
+		var num json.Number
+		if err := dec.Decode(&num); err != nil {
+			return err
+		}
+		if num == "" {
+			return nil
+		}
+
+		v, err := parseCounter(num)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = v
+		return nil
+	})
+}
+
+// decodeObject reads a JSON object, or null, from dec and calls field
+// with each of its keys so it decodes the value from dec.
+func decodeObject(dec *json.Decoder, field func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(tok.(string)); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// skipValue reads the next JSON value from dec and discards it.
+func skipValue(dec *json.Decoder) error {
+	var v json.RawMessage
+	return dec.Decode(&v)
+}
+
+// parseCounter parses a JSON number counter. cmetrics backed responses
+// may format counters as floats, like 1234.0, which are rounded
+// to the nearest integer, halfway away from zero.
+// Negative counters and counters beyond the uint64 range are errors.
+func parseCounter(n json.Number) (uint64, error) {
+	if v, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return v, nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid counter %q", n)
+	}
+
+	f = math.Round(f)
+	if f < 0 {
+		return 0, fmt.Errorf("negative counter %s", n)
+	}
+
+	// float64(math.MaxUint64) rounds up to 2^64, which is out of range.
+	if f >= math.MaxUint64 {
+		return 0, fmt.Errorf("counter %s out of range", n)
+	}
+
+	return uint64(f), nil
+}
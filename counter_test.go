@@ -0,0 +1,66 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_parseCounter(t *testing.T) {
+	tt := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "1234", want: 1234},
+		{in: "18446744073709551615", want: 18446744073709551615},
+		{in: "1234.0", want: 1234},
+		{in: "1234.4", want: 1234},
+		{in: "1234.5", want: 1235},
+		{in: "1.5e3", want: 1500},
+		{in: "-0.4", want: 0},
+		{in: "18446744073709549568.0", want: 18446744073709549568},
+		{in: "18446744073709551615.0", wantErr: true},
+		{in: "1e20", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "-1.0", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseCounter(json.Number(tc.in))
+			if gotErr := err != nil; tc.wantErr != gotErr {
+				t.Fatalf("want error %v; got %v", tc.wantErr, err)
+			}
+			if tc.want != got {
+				t.Fatalf("want counter %d; got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMetrics_floatCounters(t *testing.T) {
+	var mm Metrics
+	err := json.Unmarshal([]byte(`{
+		"input": {"cpu.0": {"records": 10.0, "bytes": 1234.0}},
+		"filter": {"grep.0": {"drop_records": 1.0, "add_records": 0}},
+		"output": {"stdout.0": {"proc_records": 9.0, "proc_bytes": 1000, "errors": 0.0, "retries": 1, "retries_failed": 0, "dropped_records": 2.0}}
+	}`), &mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (MetricInput{Records: 10, Bytes: 1234}), mm.Input["cpu.0"]; want != got {
+		t.Fatalf("want input %+v; got %+v", want, got)
+	}
+	if want, got := (MetricFilter{DropRecords: 1}), mm.Filter["grep.0"]; want != got {
+		t.Fatalf("want filter %+v; got %+v", want, got)
+	}
+	if want, got := (MetricOutput{ProcRecords: 9, ProcBytes: 1000, Retries: 1, DroppedRecords: 2}), mm.Output["stdout.0"]; want != got {
+		t.Fatalf("want output %+v; got %+v", want, got)
+	}
+
+	err = json.Unmarshal([]byte(`{"input": {"cpu.0": {"records": -1}}}`), &mm)
+	if err == nil {
+		t.Fatal("want error decoding negative counter")
+	}
+}
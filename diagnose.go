@@ -0,0 +1,128 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DiagnosisStatus is the outcome of a single Diagnose check.
+type DiagnosisStatus int
+
+const (
+	DiagnosisPass DiagnosisStatus = iota
+	DiagnosisFail
+	// DiagnosisSkip is reported for the checks that depend
+	// on a previous one that failed.
+	DiagnosisSkip
+)
+
+func (s DiagnosisStatus) String() string {
+	switch s {
+	case DiagnosisPass:
+		return "pass"
+	case DiagnosisFail:
+		return "fail"
+	case DiagnosisSkip:
+		return "skip"
+	default:
+		return fmt.Sprintf("DiagnosisStatus(%d)", int(s))
+	}
+}
+
+// DiagnosisCheck is the result of a single Diagnose check.
+type DiagnosisCheck struct {
+	// Name is one of "reachable", "fluent-bit", "metrics" or "storage".
+	Name   string
+	Status DiagnosisStatus
+	// Err and Remediation are set for failed checks.
+	Err         error
+	Remediation string
+}
+
+// DiagnosisReport lists the Diagnose checks in the order they ran.
+type DiagnosisReport struct {
+	Checks []DiagnosisCheck
+}
+
+// OK reports whether no check failed.
+func (r DiagnosisReport) OK() bool {
+	for _, c := range r.Checks {
+		if c.Status == DiagnosisFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Diagnose walks through the usual "why don't I get metrics" questions:
+// whether the server is reachable, whether it is Fluent Bit,
+// and whether its metrics and storage metrics are available.
+// Each failed check comes with a remediation like "enable storage.metrics On".
+// It only reads from the server, so it is safe to run at any time.
+func (c *Client) Diagnose(ctx context.Context) DiagnosisReport {
+	var report DiagnosisReport
+	failed := false
+	run := func(name string, check func() error, remediation func(error) string) {
+		if failed {
+			report.Checks = append(report.Checks, DiagnosisCheck{Name: name, Status: DiagnosisSkip})
+			return
+		}
+
+		err := check()
+		if err == nil {
+			report.Checks = append(report.Checks, DiagnosisCheck{Name: name, Status: DiagnosisPass})
+			return
+		}
+
+		hint := remediation(err)
+		if hint == "" {
+			hint = "check the Fluent Bit logs for why the request failed"
+		}
+		report.Checks = append(report.Checks, DiagnosisCheck{
+			Name:        name,
+			Status:      DiagnosisFail,
+			Err:         err,
+			Remediation: hint,
+		})
+	}
+
+	// the server is reachable as soon as it answers, whatever the status;
+	// a bad status is reported by the "fluent-bit" check.
+	run("reachable", func() error {
+		err := c.CheckEndpoint(ctx, "/")
+		var se statusError
+		if errors.Is(err, ErrEndpointNotFound) || errors.As(err, &se) {
+			return nil
+		}
+		failed = err != nil
+		return err
+	}, Hint)
+
+	run("fluent-bit", func() error {
+		info, err := c.BuildInfo(ctx)
+		if err == nil && info.FluentBit.Version == "" {
+			err = errors.New("no Fluent Bit version reported")
+		}
+		failed = err != nil
+		return err
+	}, func(err error) string {
+		var se statusError
+		if errors.As(err, &se) {
+			return "the server answered with an error; check the Fluent Bit logs"
+		}
+		return "the server doesn't look like Fluent Bit; check that the base URL matches its HTTP_Listen and HTTP_Port"
+	})
+
+	run("metrics", func() error {
+		_, err := c.Metrics(ctx)
+		return err
+	}, Hint)
+
+	run("storage", func() error {
+		_, err := c.StorageMetrics(ctx)
+		return err
+	}, Hint)
+
+	return report
+}
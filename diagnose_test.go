@@ -0,0 +1,106 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Diagnose(t *testing.T) {
+	fluentBit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `{"fluent-bit":{"version":"1.8.15","edition":"Community","flags":[]}}`)
+		case "/api/v1/metrics":
+			fmt.Fprint(w, `{"input":{},"filter":{},"output":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fluentBit.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html></html>`)
+	}))
+	defer other.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	// hint is the remediation of the first failed check.
+	tt := []struct {
+		name    string
+		baseURL string
+		want    []DiagnosisStatus
+		hint    string
+	}{
+		{
+			name:    "storage disabled",
+			baseURL: fluentBit.URL,
+			want:    []DiagnosisStatus{DiagnosisPass, DiagnosisPass, DiagnosisPass, DiagnosisFail},
+			hint:    "enable storage.metrics On in the [SERVICE] section",
+		},
+		{
+			name:    "not fluent bit",
+			baseURL: other.URL,
+			want:    []DiagnosisStatus{DiagnosisPass, DiagnosisFail, DiagnosisSkip, DiagnosisSkip},
+			hint:    "the server doesn't look like Fluent Bit; check that the base URL matches its HTTP_Listen and HTTP_Port",
+		},
+		{
+			name:    "server error",
+			baseURL: broken.URL,
+			want:    []DiagnosisStatus{DiagnosisPass, DiagnosisFail, DiagnosisSkip, DiagnosisSkip},
+			hint:    "the server answered with an error; check the Fluent Bit logs",
+		},
+		{
+			name:    "unreachable",
+			baseURL: closed.URL,
+			want:    []DiagnosisStatus{DiagnosisFail, DiagnosisSkip, DiagnosisSkip, DiagnosisSkip},
+			hint:    Hint(ErrServerUnreachable),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			client := &Client{
+				HTTPClient: http.DefaultClient,
+				BaseURL:    tc.baseURL,
+			}
+
+			report := client.Diagnose(ctx)
+			if report.OK() {
+				t.Fatal("want report not ok")
+			}
+
+			if want, got := len(tc.want), len(report.Checks); want != got {
+				t.Fatalf("want %d checks; got %d", want, got)
+			}
+
+			hinted := false
+			for i, c := range report.Checks {
+				if want, got := tc.want[i], c.Status; want != got {
+					t.Fatalf("want check %s to %s; got %s: %v", c.Name, want, got, c.Err)
+				}
+				if c.Status == DiagnosisFail && c.Remediation == "" {
+					t.Fatalf("want check %s with a remediation: %v", c.Name, c.Err)
+				}
+				if c.Status == DiagnosisFail && !hinted {
+					hinted = true
+					if c.Remediation != tc.hint {
+						t.Fatalf("want check %s remediation %q; got %q", c.Name, tc.hint, c.Remediation)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrResponseTooLarge is returned when a response body exceeds Client.MaxResponseSize.
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrEndpointNotFound is returned when an endpoint responds with 404
+	// and is not retried.
+	ErrEndpointNotFound = errors.New("endpoint not found")
+	// ErrServerUnreachable is returned when no HTTP server could be reached
+	// at the client base URL.
+	ErrServerUnreachable = errors.New("server unreachable")
+	// ErrStorageMetricsDisabled is returned by StorageMetrics when
+	// storage.metrics is not On. It matches ErrEndpointNotFound too.
+	ErrStorageMetricsDisabled = fmt.Errorf("storage metrics disabled: %w", ErrEndpointNotFound)
+	// ErrStorageTimeout is returned by StorageMetrics when the storage
+	// endpoint doesn't respond in time. It matches context.DeadlineExceeded too.
+	ErrStorageTimeout = fmt.Errorf("storage metrics timeout: %w", context.DeadlineExceeded)
+	// ErrRedirectDisabled is returned when a response redirects
+	// while Client.DisableRedirects is set.
+	ErrRedirectDisabled = errors.New("redirect disabled")
+	// ErrCircuitOpen is returned by BreakerClient while its circuit is open.
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrVersionTooOld is returned when the Fluent Bit version
+	// is older than Client.MinVersion.
+	ErrVersionTooOld = errors.New("fluent bit version too old")
+)
+
+// EndpointError records an error along with the endpoint that caused it.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Endpoint, e.Err)
+}
+
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// listenHint explains the most common cause of refused connections.
+const listenHint = "HTTP_Listen 127.0.0.1 only accepts connections from the same host or container, use 0.0.0.0 to scrape from elsewhere"
+
+// unreachableError wraps the transport error of a request that
+// couldn't reach the server as ErrServerUnreachable,
+// adding listenHint when the connection was refused.
+func unreachableError(err error) error {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%w: %v (%s)", ErrServerUnreachable, err, listenHint)
+	}
+	return fmt.Errorf("%w: %v", ErrServerUnreachable, err)
+}
+
+// isDialError reports whether err is a failure to connect to the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// storageTimeoutError is ErrStorageTimeout keeping its cause in the chain.
+type storageTimeoutError struct {
+	err error
+}
+
+func (e *storageTimeoutError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrStorageTimeout, e.err)
+}
+
+// Is matches ErrStorageTimeout and what it wraps.
+func (e *storageTimeoutError) Is(target error) bool {
+	return errors.Is(ErrStorageTimeout, target)
+}
+
+func (e *storageTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// statusError is the error of a response with an unexpected status code.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("failed with status code %d", int(e))
+}
+
+// InstanceError records an error along with the base URL
+// of the Fluent Bit instance that caused it.
+type InstanceError struct {
+	BaseURL string
+	Err     error
+}
+
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.BaseURL, e.Err)
+}
+
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// ScrapeErrors aggregates the errors of an operation spanning
+// several endpoints or instances, usually *EndpointError
+// or *InstanceError values.
+// errors.Is and errors.As match against each of them.
+type ScrapeErrors []error
+
+func (e ScrapeErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d scrapes failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e ScrapeErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ScrapeErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errOrNil returns e as an error, or nil if e is empty.
+func (e ScrapeErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Hint returns a human readable remediation hint for err,
+// or an empty string if there is none.
+func Hint(err error) string {
+	if errors.Is(err, ErrServerUnreachable) {
+		return "make sure Fluent Bit is running with HTTP_Server On and that HTTP_Listen and HTTP_Port match the client base URL; " + listenHint
+	}
+
+	if errors.Is(err, ErrStorageTimeout) {
+		return "the storage endpoint didn't respond in time; raise the timeout with a context deadline or Client.DefaultTimeout"
+	}
+
+	if !errors.Is(err, ErrEndpointNotFound) {
+		return ""
+	}
+
+	var endpoint string
+	var e *EndpointError
+	if errors.As(err, &e) {
+		endpoint = e.Endpoint
+	}
+
+	switch endpoint {
+	case "/api/v1/storage":
+		return "enable storage.metrics On in the [SERVICE] section"
+	case "/api/v1/health":
+		return "enable Health_Check On in the [SERVICE] section"
+	case "/api/v2/reload":
+		return "hot reload requires Fluent Bit v2.1 or later with Hot_Reload On"
+	case "/api/v2/metrics", "/api/v2/metrics/prometheus":
+		return "v2 metrics require Fluent Bit v1.9 or later"
+	default:
+		return "the endpoint is not supported by the running Fluent Bit version"
+	}
+}
@@ -0,0 +1,151 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_CheckEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	if err := client.CheckEndpoint(ctx, "/api/v1/metrics"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.CheckEndpoint(ctx, "/api/v1/storage")
+	if !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+
+	if want, got := "storage.metrics On", Hint(err); !strings.Contains(got, want) {
+		t.Fatalf("want hint to contain %q; got %q", want, got)
+	}
+
+	srv.Close()
+
+	err = client.CheckEndpoint(ctx, "/api/v1/metrics")
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Fatalf("want error %v; got %v", ErrServerUnreachable, err)
+	}
+
+	if want, got := "HTTP_Server On", Hint(err); !strings.Contains(got, want) {
+		t.Fatalf("want hint to contain %q; got %q", want, got)
+	}
+}
+
+func TestClient_CheckEndpoint_transportError(t *testing.T) {
+	// the server is reached but hangs up without responding.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	err := client.CheckEndpoint(context.Background(), "/api/v1/metrics")
+	if err == nil {
+		t.Fatal("want error")
+	}
+
+	if errors.Is(err, ErrServerUnreachable) {
+		t.Fatalf("want error other than %v; got %v", ErrServerUnreachable, err)
+	}
+
+	var endpointErr *EndpointError
+	if !errors.As(err, &endpointErr) || endpointErr.Endpoint != "/api/v1/metrics" {
+		t.Fatalf("want endpoint error; got %v", err)
+	}
+}
+
+func TestClient_connectionRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	// a deadline landing on an attempt must not hide the dial errors.
+	client := &Client{
+		HTTPClient:     srv.Client(),
+		BaseURL:        srv.URL,
+		DefaultTimeout: 4 * DefaultHTTPRetryBackoff,
+	}
+
+	var err error
+	for i := 0; i < 3; i++ {
+		_, err = client.UpTime(context.Background())
+		if !errors.Is(err, ErrServerUnreachable) {
+			t.Fatalf("want error %v; got %v", ErrServerUnreachable, err)
+		}
+	}
+
+	var e *EndpointError
+	if !errors.As(err, &e) || e.Endpoint != "/api/v1/uptime" {
+		t.Fatalf("want uptime endpoint error; got %v", err)
+	}
+
+	if want, got := "HTTP_Listen 127.0.0.1", err.Error(); !strings.Contains(got, want) {
+		t.Fatalf("want error to contain %q; got %q", want, got)
+	}
+}
+
+func TestHint(t *testing.T) {
+	if got := Hint(errors.New("some error")); got != "" {
+		t.Fatalf("want no hint; got %q", got)
+	}
+
+	if got := Hint(nil); got != "" {
+		t.Fatalf("want no hint; got %q", got)
+	}
+
+	err := &EndpointError{Endpoint: "/api/v1/unknown", Err: ErrEndpointNotFound}
+	if got := Hint(err); got == "" {
+		t.Fatal("want generic not found hint")
+	}
+}
+
+func TestScrapeErrors(t *testing.T) {
+	storageErr := &EndpointError{Endpoint: "/api/v1/storage", Err: ErrEndpointNotFound}
+	var err error = ScrapeErrors{
+		errors.New("some error"),
+		storageErr,
+	}
+
+	if !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error to match %v", ErrEndpointNotFound)
+	}
+	if errors.Is(err, ErrServerUnreachable) {
+		t.Fatalf("want error not to match %v", ErrServerUnreachable)
+	}
+
+	var e *EndpointError
+	if !errors.As(err, &e) || e != storageErr {
+		t.Fatalf("want endpoint error %v; got %v", storageErr, e)
+	}
+
+	want := "2 scrapes failed: some error; /api/v1/storage: endpoint not found"
+	if got := err.Error(); want != got {
+		t.Fatalf("want message %q; got %q", want, got)
+	}
+
+	if want, got := "some error", (ScrapeErrors{errors.New("some error")}).Error(); want != got {
+		t.Fatalf("want message %q; got %q", want, got)
+	}
+}
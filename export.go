@@ -0,0 +1,143 @@
+package fluentbit
+
+import (
+	"fmt"
+	"sort"
+)
+
+// counter is a single plugin counter flattened out of Metrics,
+// the common ground of the export formats.
+type counter struct {
+	section string // input, filter or output
+	plugin  string // plugin instance name
+	field   string // JSON field name, e.g. proc_records
+	value   uint64
+}
+
+// counters flattens m sorted by section, plugin and field order.
+func (m Metrics) counters() []counter {
+	var out []counter
+	for _, name := range sortedKeys(m.Input) {
+		in := m.Input[name]
+		out = append(out,
+			counter{"input", name, "records", in.Records},
+			counter{"input", name, "bytes", in.Bytes},
+		)
+	}
+	for _, name := range sortedKeys(m.Filter) {
+		f := m.Filter[name]
+		out = append(out,
+			counter{"filter", name, "drop_records", f.DropRecords},
+			counter{"filter", name, "add_records", f.AddRecords},
+			counter{"filter", name, "emit_records", f.EmitRecords},
+		)
+	}
+	for _, name := range sortedKeys(m.Output) {
+		o := m.Output[name]
+		out = append(out,
+			counter{"output", name, "proc_records", o.ProcRecords},
+			counter{"output", name, "proc_bytes", o.ProcBytes},
+			counter{"output", name, "errors", o.Errors},
+			counter{"output", name, "retries", o.Retries},
+			counter{"output", name, "retries_failed", o.RetriesFailed},
+			counter{"output", name, "dropped_records", o.DroppedRecords},
+			counter{"output", name, "retried_records", o.RetriedRecords},
+		)
+	}
+	return out
+}
+
+// sortedKeys returns the keys of a plugin metrics map in order.
+func sortedKeys(m interface{}) []string {
+	var out []string
+	switch m := m.(type) {
+	case map[string]MetricInput:
+		for k := range m {
+			out = append(out, k)
+		}
+	case map[string]MetricFilter:
+		for k := range m {
+			out = append(out, k)
+		}
+	case map[string]MetricOutput:
+		for k := range m {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// MetricNamer names the metric of a plugin counter in an export format.
+// section is one of input, filter or output, plugin is the plugin instance
+// name and field is the JSON field name of the counter, e.g. proc_records.
+type MetricNamer func(section, plugin, field string) string
+
+// PrometheusNamer is the default RemoteWriter namer. It returns
+// the names Fluent Bit uses on its own Prometheus endpoint,
+// e.g. "fluentbit_output_proc_records_total", leaving the plugin to a label.
+func PrometheusNamer(section, plugin, field string) string {
+	return "fluentbit_" + section + "_" + field + "_total"
+}
+
+// StatsDNamer is the default StatsDWriter namer. It returns dot separated
+// names, e.g. "output.stdout.0.proc_records", with the characters
+// StatsD reserves in the plugin name replaced by underscores.
+func StatsDNamer(section, plugin, field string) string {
+	return section + "." + statsDReplacer.Replace(plugin) + "." + field
+}
+
+func (n MetricNamer) name(c counter) string {
+	return n(c.section, c.plugin, c.field)
+}
+
+// namerOrDefault returns n, or def if n is nil.
+func namerOrDefault(n, def MetricNamer) MetricNamer {
+	if n == nil {
+		return def
+	}
+	return n
+}
+
+// ValidateLabels checks the static labels attached by the exporters.
+// Label names must match the Prometheus label name syntax [a-zA-Z_][a-zA-Z0-9_]*,
+// must not start with "__", which Prometheus reserves for internal use,
+// and must not be "name", which holds the plugin instance name.
+func ValidateLabels(labels map[string]string) error {
+	for k := range labels {
+		if !isLabelName(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+		if len(k) >= 2 && k[:2] == "__" {
+			return fmt.Errorf("reserved label name %q", k)
+		}
+		if k == "name" {
+			return fmt.Errorf("label name %q collides with the plugin name label", k)
+		}
+	}
+	return nil
+}
+
+func isLabelName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (i > 0 && b >= '0' && b <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// sortedLabels returns labels as name-value pairs sorted by name.
+func sortedLabels(labels map[string]string) [][2]string {
+	out := make([][2]string, 0, len(labels))
+	for k, v := range labels {
+		out = append(out, [2]string{k, v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
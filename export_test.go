@@ -0,0 +1,24 @@
+package fluentbit
+
+import "testing"
+
+func TestValidateLabels(t *testing.T) {
+	tt := []struct {
+		labels  map[string]string
+		wantErr bool
+	}{
+		{nil, false},
+		{map[string]string{"tenant": "acme", "_cluster2": "eu-1"}, false},
+		{map[string]string{"": "x"}, true},
+		{map[string]string{"2cluster": "x"}, true},
+		{map[string]string{"tenant-id": "x"}, true},
+		{map[string]string{"__tenant": "x"}, true},
+		{map[string]string{"name": "x"}, true},
+	}
+	for _, tc := range tt {
+		err := ValidateLabels(tc.labels)
+		if gotErr := err != nil; tc.wantErr != gotErr {
+			t.Errorf("labels %v: want error %v; got %v", tc.labels, tc.wantErr, err)
+		}
+	}
+}
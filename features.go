@@ -0,0 +1,58 @@
+package fluentbit
+
+import "context"
+
+// Feature reports whether a Fluent Bit feature is compiled in, according
+// to the build flags, and enabled in the running configuration, according
+// to its monitoring endpoint.
+// Compiled is true for features no build flag gates, and Enabled follows
+// Compiled for features without an endpoint to probe.
+type Feature struct {
+	Compiled bool
+	Enabled  bool
+}
+
+// Available reports whether the feature can be used right away.
+func (f Feature) Available() bool {
+	return f.Compiled && f.Enabled
+}
+
+// Features reports the features of the running Fluent Bit instance.
+type Features struct {
+	TLS               Feature // FLB_HAVE_TLS
+	Metrics           Feature // FLB_HAVE_METRICS, GET /api/v1/metrics
+	PrometheusMetrics Feature // FLB_HAVE_METRICS, GET /api/v2/metrics/prometheus
+	Storage           Feature // GET /api/v1/storage, with storage.metrics On
+	Health            Feature // GET /api/v1/health, with Health_Check On
+	StreamProcessor   Feature // FLB_HAVE_STREAM_PROCESSOR
+	HotReload         Feature // GET /api/v2/reload, v2.1 onwards
+}
+
+// Features combines the build flags of BuildInfo with the live
+// endpoints of Capabilities, and fails if either does.
+func (c *Client) Features(ctx context.Context) (Features, error) {
+	info, err := c.BuildInfo(ctx)
+	if err != nil {
+		return Features{}, err
+	}
+
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return Features{}, err
+	}
+
+	compiled := func(flag string) Feature {
+		ok := info.HasFlag(flag)
+		return Feature{Compiled: ok, Enabled: ok}
+	}
+
+	return Features{
+		TLS:               compiled("TLS"),
+		Metrics:           Feature{Compiled: info.HasFlag("METRICS"), Enabled: caps.Metrics},
+		PrometheusMetrics: Feature{Compiled: info.HasFlag("METRICS"), Enabled: caps.PrometheusMetrics},
+		Storage:           Feature{Compiled: true, Enabled: caps.Storage},
+		Health:            Feature{Compiled: true, Enabled: caps.Health},
+		StreamProcessor:   compiled("STREAM_PROCESSOR"),
+		HotReload:         Feature{Compiled: true, Enabled: caps.Reload},
+	}, nil
+}
@@ -0,0 +1,54 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Features(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `{"fluent-bit":{"version":"1.9.3","edition":"Community","flags":["FLB_HAVE_TLS","FLB_HAVE_METRICS"]}}`)
+		case "/api/v1/metrics", "/api/v2/metrics/prometheus":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	got, err := client.Features(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Features{
+		TLS:               Feature{Compiled: true, Enabled: true},
+		Metrics:           Feature{Compiled: true, Enabled: true},
+		PrometheusMetrics: Feature{Compiled: true, Enabled: true},
+		Storage:           Feature{Compiled: true},
+		Health:            Feature{Compiled: true},
+		StreamProcessor:   Feature{},
+		HotReload:         Feature{Compiled: true},
+	}
+	if want != got {
+		t.Fatalf("want features %+v; got %+v", want, got)
+	}
+
+	if got.Storage.Available() {
+		t.Fatal("want storage unavailable")
+	}
+
+	if !got.TLS.Available() {
+		t.Fatal("want tls available")
+	}
+}
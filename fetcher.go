@@ -0,0 +1,36 @@
+package fluentbit
+
+import "context"
+
+// Fetcher fetches an endpoint the Client doesn't model.
+// It is the supported way to add endpoints out of tree:
+// implementations call c.Do, which applies the client transport,
+// retries, timeouts and RequestBuilder, and decode the result.
+//
+//	type v2MetricsFetcher struct{}
+//
+//	func (v2MetricsFetcher) Fetch(ctx context.Context, c *fluentbit.Client) (interface{}, error) {
+//		var raw json.RawMessage
+//		if err := c.Do(ctx, "/api/v2/metrics", &raw); err != nil {
+//			return nil, err
+//		}
+//		return fluentbit.DecodeMetrics(raw)
+//	}
+type Fetcher interface {
+	Fetch(ctx context.Context, c *Client) (interface{}, error)
+}
+
+// FetcherFunc adapts a function to a Fetcher.
+type FetcherFunc func(ctx context.Context, c *Client) (interface{}, error)
+
+func (f FetcherFunc) Fetch(ctx context.Context, c *Client) (interface{}, error) {
+	return f(ctx, c)
+}
+
+// Run runs f against c, bounding it by DefaultTimeout
+// when ctx has no deadline, like the typed methods.
+func (c *Client) Run(ctx context.Context, f Fetcher) (interface{}, error) {
+	ctx, cancel := c.withTimeout(ctx, 0)
+	defer cancel()
+	return f.Fetch(ctx, c)
+}
@@ -0,0 +1,65 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// v2MetricsFetcher is an example Fetcher for GET /api/v2/metrics,
+// which the Client doesn't model.
+type v2MetricsFetcher struct{}
+
+func (v2MetricsFetcher) Fetch(ctx context.Context, c *Client) (interface{}, error) {
+	var raw json.RawMessage
+	if err := c.Do(ctx, "/api/v2/metrics", &raw); err != nil {
+		return nil, err
+	}
+	return DecodeMetrics(raw)
+}
+
+func TestClient_Run(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, "testdata/metrics_v2.json")
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	v, err := client.Run(context.Background(), v2MetricsFetcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm, ok := v.(Metrics)
+	if !ok {
+		t.Fatalf("want Metrics; got %T", v)
+	}
+	if mm.IsEmpty() {
+		t.Fatal("want v2 metrics")
+	}
+}
+
+func TestClient_Run_DefaultTimeout(t *testing.T) {
+	client := &Client{DefaultTimeout: time.Minute}
+
+	_, err := client.Run(context.Background(), FetcherFunc(func(ctx context.Context, c *Client) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("want fetcher context bounded by DefaultTimeout")
+		}
+		return nil, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package fluentbit
+
+import "context"
+
+// FleetHealth summarizes the health of the instances of a Scraper.
+type FleetHealth struct {
+	// Healthy counts the instances whose CheckHealth level is OK,
+	// Degraded the ones at WARNING or CRITICAL and Unreachable
+	// the ones that couldn't be checked.
+	Healthy     int
+	Degraded    int
+	Unreachable int
+	// Problems lists the instances that are not healthy,
+	// in the order of the scraper clients.
+	Problems []FleetProblem
+}
+
+// FleetProblem is an instance that is not healthy.
+type FleetProblem struct {
+	Target string
+	// Level is the CheckHealth level, CheckCritical if unreachable.
+	Level CheckLevel
+	// Reason is the CheckHealth message or the scrape error.
+	Reason string
+}
+
+// FleetHealth runs CheckHealth with the given thresholds against
+// every instance, with the scraper concurrency and rate limit,
+// and sums them up into a single fleet view.
+// Instances that failed are counted as unreachable and also
+// reported together as ScrapeErrors of *InstanceError;
+// the FleetHealth is complete either way.
+func (s *Scraper) FleetHealth(ctx context.Context, thresholds CheckThresholds) (FleetHealth, error) {
+	results := make([]CheckResult, len(s.clients))
+	errs := make([]error, len(s.clients))
+	s.each(ctx, func(ctx context.Context, i int) bool {
+		c := s.clients[i]
+		res, err := c.CheckHealth(ctx, thresholds)
+		if err != nil {
+			errs[i] = &InstanceError{BaseURL: c.BaseURL, Err: err}
+		}
+		results[i] = res
+		return true
+	})
+
+	var fh FleetHealth
+	var failed ScrapeErrors
+	for i, c := range s.clients {
+		if err := errs[i]; err != nil {
+			fh.Unreachable++
+			fh.Problems = append(fh.Problems, FleetProblem{Target: c.BaseURL, Level: CheckCritical, Reason: err.Error()})
+			failed = append(failed, err)
+			continue
+		}
+
+		res := results[i]
+		if res.Level == CheckOK {
+			fh.Healthy++
+			continue
+		}
+
+		fh.Degraded++
+		fh.Problems = append(fh.Problems, FleetProblem{Target: c.BaseURL, Level: res.Level, Reason: res.Message})
+	}
+
+	return fh, failed.errOrNil()
+}
@@ -0,0 +1,67 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScraper_FleetHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/sick/") && strings.HasSuffix(r.URL.Path, "/api/v1/health"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "error")
+		case strings.HasSuffix(r.URL.Path, "/api/v1/health"):
+			fmt.Fprint(w, "ok")
+		case strings.HasSuffix(r.URL.Path, "/api/v1/metrics"):
+			fmt.Fprint(w, `{"input":{"cpu.0":{"records":1}},"output":{"stdout.0":{"proc_records":1}}}`)
+		case strings.HasSuffix(r.URL.Path, "/api/v1/uptime"):
+			fmt.Fprint(w, `{"uptime_sec":10}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	var clients []*Client
+	for _, baseURL := range []string{srv.URL + "/a", srv.URL + "/sick", closed.URL, srv.URL + "/b"} {
+		clients = append(clients, &Client{
+			HTTPClient:     http.DefaultClient,
+			BaseURL:        baseURL,
+			DefaultTimeout: 500 * time.Millisecond,
+		})
+	}
+
+	fh, err := NewScraper(clients, ScraperOptions{Concurrency: 2}).FleetHealth(context.Background(), CheckThresholds{})
+
+	var ie *InstanceError
+	if !errors.As(err, &ie) || ie.BaseURL != closed.URL {
+		t.Fatalf("want instance error for the unreachable instance; got %v", err)
+	}
+
+	if want, got := [3]int{2, 1, 1}, [3]int{fh.Healthy, fh.Degraded, fh.Unreachable}; want != got {
+		t.Fatalf("want healthy, degraded and unreachable %v; got %v", want, got)
+	}
+
+	var targets []string
+	for _, p := range fh.Problems {
+		targets = append(targets, p.Target)
+	}
+	if want := []string{srv.URL + "/sick", closed.URL}; !reflect.DeepEqual(want, targets) {
+		t.Fatalf("want problems %v; got %v", want, targets)
+	}
+
+	if want, got := "CRITICAL: health check failing", fh.Problems[0].Reason; want != got {
+		t.Fatalf("want reason %q; got %q", want, got)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build go1.18
+// +build go1.18
+
+package fluentbit
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func addSeedFiles(f *testing.F, files ...string) {
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(raw)
+	}
+}
+
+func FuzzDecodeMetrics(f *testing.F) {
+	addSeedFiles(f,
+		"testdata/metrics_v1_8.json",
+		"testdata/metrics_v1_9.json",
+		"testdata/metrics_v2.json",
+		"testdata/prometheus_v2.txt",
+	)
+	f.Add([]byte(`{"metrics":[{"meta":{"labels":["a","b"]},"values":[{"labels":["x"]}]}]}`))
+	f.Add([]byte(`{"input":{"cpu.0":{"records":1e400}}}`))
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		mm, err := DecodeMetrics(raw)
+		if err != nil {
+			return
+		}
+
+		// exercise the helpers on whatever got decoded.
+		_ = mm.Balance()
+		_ = mm.Plugins()
+		_ = mm.FiltersOrdered()
+		_ = mm.WriteStatsD(&bytes.Buffer{}, "fuzz")
+	})
+}
+
+func FuzzDecodeStorageMetrics(f *testing.F) {
+	addSeedFiles(f, "testdata/storage_v1_8.json")
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		sm, err := DecodeStorageMetrics(raw)
+		if err != nil {
+			return
+		}
+
+		_ = sm.PausedInputs()
+		_, _ = StorageDiff(sm, sm)
+	})
+}
+
+func FuzzParsePrometheus(f *testing.F) {
+	addSeedFiles(f, "testdata/prometheus_v2.txt")
+	f.Add([]byte(`a{b="\`))
+	f.Add([]byte("a{b=\"\xff\"} NaN"))
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		samples, err := ParsePrometheus(bytes.NewReader(raw))
+		if err != nil {
+			return
+		}
+
+		_ = MetricsFromPrometheus(samples)
+		_, _ = UpTimeFromPrometheus(samples)
+	})
+}
+
+func FuzzParseSize(f *testing.F) {
+	for _, s := range []string{"0b", "1.5K", "30.2M", "1e308G", "-1K", "."} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := ParseSize(s)
+		if err != nil {
+			return
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(s), "-") && got != 0 {
+			t.Fatalf("ParseSize(%q) = %d from a negative size", s, got)
+		}
+		// A wrapped result lands near math.MaxUint64, which is out of
+		// range when parsed back.
+		again, err := ParseSize(strconv.FormatUint(got, 10))
+		if err != nil || again != got {
+			t.Fatalf("ParseSize(%q) = %d, which parses back as %d, %v", s, got, again, err)
+		}
+	})
+}
@@ -3,6 +3,9 @@ module github.com/calyptia/go-fluent-bit-metrics
 go 1.16
 
 require (
+	github.com/golang/snappy v0.0.4
 	github.com/hashicorp/go-version v1.3.0
 	github.com/ory/dockertest/v3 v3.7.0
+	go.uber.org/goleak v1.1.10
+	golang.org/x/net v0.0.0-20201021035429-f5854403a974
 )
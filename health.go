@@ -0,0 +1,50 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// HealthStatus payload returned by GET /api/v1/health
+//
+// Fluent Bit evaluates its health check over a sliding window of
+// HC_Period seconds, flipping to unhealthy once the output errors exceed
+// HC_Errors_Count or the failed retries exceed HC_Retry_Failure_Count.
+// Up to v2.x the endpoint only reports the outcome, as "ok" with 200
+// or "error" with 500: neither the window nor the counts in it are exposed,
+// so only Healthy and the raw Status are available.
+// To see why it flipped, compare output Errors and RetriesFailed rates
+// from two Metrics snapshots against the configured thresholds.
+type HealthStatus struct {
+	Healthy bool
+	// Status is the raw response body, e.g. "ok" or "error".
+	Status string
+}
+
+// Health returns the health check outcome.
+// It requires Fluent Bit v1.8 or later with Health_Check On,
+// otherwise it fails with ErrEndpointNotFound.
+func (c *Client) Health(ctx context.Context) (HealthStatus, error) {
+	var hs HealthStatus
+	err := c.fetch(ctx, "/api/v1/health", c.RetryNotFound, func(r io.Reader) error {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		hs.Status = strings.TrimSpace(string(b))
+		hs.Healthy = true
+		return nil
+	})
+
+	var se statusError
+	if errors.As(err, &se) && se == http.StatusInternalServerError {
+		return HealthStatus{Status: "error"}, nil
+	}
+
+	return hs, err
+}
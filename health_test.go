@@ -0,0 +1,56 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Health(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "error")
+			return
+		}
+		fmt.Fprint(w, "ok\n")
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	hs, err := client.Health(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (HealthStatus{Healthy: true, Status: "ok"}), hs; want != got {
+		t.Fatalf("want health %+v; got %+v", want, got)
+	}
+
+	healthy = false
+	hs, err = client.Health(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (HealthStatus{Status: "error"}), hs; want != got {
+		t.Fatalf("want health %+v; got %+v", want, got)
+	}
+
+	client.BaseURL = srv.URL + "/disabled"
+	if _, err := client.Health(context.Background()); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+}
@@ -0,0 +1,36 @@
+package fluentbit
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// DefaultH2CDialTimeout bounds connecting in NewH2CTransport.
+const DefaultH2CDialTimeout = 5 * time.Second
+
+// NewH2CTransport returns a transport speaking cleartext HTTP/2 (h2c)
+// with prior knowledge, for service meshes that mandate it on http:// URLs.
+// The standard transport only negotiates HTTP/2 over TLS.
+// It relies on golang.org/x/net/http2.
+//
+// The http2 package dials without the request context, so connecting
+// is bounded by DefaultH2CDialTimeout instead of the request deadline
+// and a blackholed host can't hang requests forever.
+//
+//	client := &fluentbit.Client{
+//		HTTPClient: &http.Client{Transport: fluentbit.NewH2CTransport()},
+//		BaseURL:    "http://localhost:2020",
+//	}
+func NewH2CTransport() http.RoundTripper {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			d := net.Dialer{Timeout: DefaultH2CDialTimeout}
+			return d.Dial(network, addr)
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestNewH2CTransport(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	})
+	srv := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: &http.Client{Transport: NewH2CTransport()},
+		BaseURL:    srv.URL,
+	}
+
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("want uptime %d; got %d", want, got)
+	}
+}
+
+func TestNewH2CTransport_dial(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.Listener.Addr().String()
+
+	tr := NewH2CTransport().(*http2.Transport)
+	conn, err := tr.DialTLS("tcp", addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	srv.Close()
+	if _, err := tr.DialTLS("tcp", addr, nil); err == nil {
+		t.Fatal("want error dialing a closed server")
+	}
+}
@@ -0,0 +1,77 @@
+package fluentbit
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteInfluxLineProtocol writes m in InfluxDB line protocol,
+// a line per plugin instance with a field per counter, e.g.
+// "fluentbit,name=stdout.0,section=output proc_records=123i,... 1600000000000000000".
+// Each line is tagged with the section (input, filter or output) and
+// the plugin instance name, which take precedence over the given tags.
+// Tags with an empty value are skipped as line protocol doesn't allow them.
+// Counters are written as integers, capped to the int64 range,
+// and ts with nanosecond precision.
+func (m Metrics) WriteInfluxLineProtocol(w io.Writer, measurement string, tags map[string]string, ts time.Time) error {
+	bw := bufio.NewWriter(w)
+	cc := m.counters()
+	for i := 0; i < len(cc); {
+		c := cc[i]
+
+		lineTags := map[string]string{}
+		for k, v := range tags {
+			lineTags[k] = v
+		}
+		lineTags["section"] = c.section
+		lineTags["name"] = c.plugin
+
+		bw.WriteString(influxMeasurementReplacer.Replace(measurement))
+		keys := make([]string, 0, len(lineTags))
+		for k, v := range lineTags {
+			if k != "" && v != "" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			bw.WriteByte(',')
+			bw.WriteString(influxKeyReplacer.Replace(k))
+			bw.WriteByte('=')
+			bw.WriteString(influxKeyReplacer.Replace(lineTags[k]))
+		}
+
+		for sep := byte(' '); i < len(cc) && cc[i].section == c.section && cc[i].plugin == c.plugin; i++ {
+			bw.WriteByte(sep)
+			sep = ','
+			bw.WriteString(influxKeyReplacer.Replace(cc[i].field))
+			bw.WriteByte('=')
+			bw.WriteString(strconv.FormatUint(influxInt(cc[i].value), 10))
+			bw.WriteByte('i')
+		}
+
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatInt(ts.UnixNano(), 10))
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// influxInt caps v to the line protocol integer range.
+func influxInt(v uint64) uint64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return v
+}
+
+var (
+	influxMeasurementReplacer = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	influxKeyReplacer         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+)
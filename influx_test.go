@@ -0,0 +1,43 @@
+package fluentbit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestMetrics_WriteInfluxLineProtocol(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 1, Bytes: 10},
+		},
+		Filter: map[string]MetricFilter{
+			"grep.0": {DropRecords: 3},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0":  {ProcRecords: 123, ProcBytes: 1230, Errors: 1, Retries: 2, RetriesFailed: 1},
+			"my out,=1": {DroppedRecords: 18446744073709551615},
+		},
+	}
+	tags := map[string]string{
+		"cluster": "eu 1",
+		"empty":   "",
+		"name":    "overridden",
+	}
+	ts := time.Date(2020, 9, 13, 12, 26, 40, 123, time.UTC)
+
+	var buf bytes.Buffer
+	if err := mm.WriteInfluxLineProtocol(&buf, "fluent bit,metrics", tags, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ioutil.ReadFile("testdata/influx.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); string(want) != got {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
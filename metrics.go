@@ -0,0 +1,186 @@
+package fluentbit
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InputByAlias returns the metrics of the input whose user-defined alias
+// matches exactly.
+func (m Metrics) InputByAlias(alias string) (MetricInput, bool) {
+	in, ok := m.Input[alias]
+	return in, ok
+}
+
+// OutputByAlias returns the metrics of the output whose user-defined alias
+// matches exactly.
+func (m Metrics) OutputByAlias(alias string) (MetricOutput, bool) {
+	out, ok := m.Output[alias]
+	return out, ok
+}
+
+// InputInstance returns the metrics of the input instance
+// of the given plugin type and index, e.g. "tail" and 2 for "tail.2".
+// It reports false for negative indices. Aliased inputs are not found by index.
+func (m Metrics) InputInstance(pluginType string, index int) (MetricInput, bool) {
+	key, ok := pluginKey(pluginType, index)
+	if !ok {
+		return MetricInput{}, false
+	}
+	in, ok := m.Input[key]
+	return in, ok
+}
+
+// FilterInstance is like InputInstance for filters.
+func (m Metrics) FilterInstance(pluginType string, index int) (MetricFilter, bool) {
+	key, ok := pluginKey(pluginType, index)
+	if !ok {
+		return MetricFilter{}, false
+	}
+	f, ok := m.Filter[key]
+	return f, ok
+}
+
+// OutputInstance is like InputInstance for outputs,
+// e.g. "forward" and 2 for "forward.2".
+func (m Metrics) OutputInstance(pluginType string, index int) (MetricOutput, bool) {
+	key, ok := pluginKey(pluginType, index)
+	if !ok {
+		return MetricOutput{}, false
+	}
+	out, ok := m.Output[key]
+	return out, ok
+}
+
+// IsEmpty reports whether m has no input, filter or output plugins,
+// as opposed to plugins that haven't seen any traffic yet.
+func (m Metrics) IsEmpty() bool {
+	return len(m.Input) == 0 && len(m.Filter) == 0 && len(m.Output) == 0
+}
+
+// HasTraffic reports whether any input, filter or output counter is nonzero.
+// Right after Fluent Bit starts every counter is zero.
+func (m Metrics) HasTraffic() bool {
+	for _, c := range m.counters() {
+		if c.value != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NamedFilter is a filter's metrics along with its instance name.
+type NamedFilter struct {
+	Name string
+	MetricFilter
+}
+
+// FiltersOrdered returns the filter metrics sorted by the numeric index
+// of their "type.index" name. Filters without an index, like aliased ones,
+// come last sorted by name.
+// The API doesn't expose the config order, but since Fluent Bit assigns
+// indexes as it loads plugins, index order approximates it.
+func (m Metrics) FiltersOrdered() []NamedFilter {
+	out := make([]NamedFilter, 0, len(m.Filter))
+	for name, f := range m.Filter {
+		out = append(out, NamedFilter{Name: name, MetricFilter: f})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		ii, iok := pluginIndex(out[i].Name)
+		ji, jok := pluginIndex(out[j].Name)
+		if iok != jok {
+			return iok
+		}
+		if iok && ii != ji {
+			return ii < ji
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// pluginKey builds the "type.index" name of a plugin instance,
+// the counterpart of pluginType and pluginIndex.
+func pluginKey(pluginType string, index int) (string, bool) {
+	if index < 0 {
+		return "", false
+	}
+	return pluginType + "." + strconv.Itoa(index), true
+}
+
+// pluginIndex extracts the index of a "type.index" plugin instance name.
+func pluginIndex(name string) (int, bool) {
+	i := strings.LastIndexByte(name, '.')
+	if i == -1 {
+		return 0, false
+	}
+
+	idx, err := strconv.Atoi(name[i+1:])
+	if err != nil || idx < 0 {
+		return 0, false
+	}
+
+	return idx, true
+}
+
+// FailureRatio returns the fraction of records the output lost,
+// that is, dropped records over all the records it handled
+// (processed plus dropped). It returns zero when nothing was handled.
+func (o MetricOutput) FailureRatio() float64 {
+	total := o.ProcRecords + o.DroppedRecords
+	if total == 0 {
+		return 0
+	}
+
+	return float64(o.DroppedRecords) / float64(total)
+}
+
+// NetRecordChange returns the net change in records the filter made
+// to the pipeline: added plus emitted minus dropped records.
+// It is negative for filters dropping more records than they create.
+func (f MetricFilter) NetRecordChange() int64 {
+	return int64(f.AddRecords) + int64(f.EmitRecords) - int64(f.DropRecords)
+}
+
+// AvgRecordBytes returns the average size in bytes of the records
+// the input ingested since start. Tiny records hint at batching
+// opportunities. It returns zero when no record was ingested.
+func (in MetricInput) AvgRecordBytes() float64 {
+	if in.Records == 0 {
+		return 0
+	}
+
+	return float64(in.Bytes) / float64(in.Records)
+}
+
+// RecentAvgRecordBytes is like AvgRecordBytes for the records ingested
+// between two snapshots of an input, to spot a recent change
+// in log verbosity. It returns zero when no record was ingested
+// in between or the counters reset.
+func RecentAvgRecordBytes(prev, curr MetricInput) float64 {
+	if curr.Records < prev.Records || curr.Bytes < prev.Bytes {
+		return 0
+	}
+
+	return MetricInput{
+		Records: curr.Records - prev.Records,
+		Bytes:   curr.Bytes - prev.Bytes,
+	}.AvgRecordBytes()
+}
+
+// SuccessfulRecords returns the records the output delivered.
+//
+// Fluent Bit only adds to proc_records once a flush succeeds, so a record
+// retried before being delivered counts once, on delivery, and the records
+// of flushes that failed for good go to dropped_records instead.
+// The retry counters don't take part: retries and retries_failed count
+// flush attempts, not records, and retried_records counts every attempt
+// scheduled for a retry, so subtracting them would undercount.
+// It is therefore ProcRecords; the raw Retries, RetriesFailed,
+// RetriedRecords and DroppedRecords fields remain available
+// for finer accounting.
+func (o MetricOutput) SuccessfulRecords() uint64 {
+	return o.ProcRecords
+}
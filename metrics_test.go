@@ -0,0 +1,238 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMetrics_OutputByAlias(t *testing.T) {
+	mm := Metrics{
+		Output: map[string]MetricOutput{
+			"stdout.0":  {ProcRecords: 1},
+			"my_stdout": {ProcRecords: 2},
+		},
+	}
+
+	got, ok := mm.OutputByAlias("my_stdout")
+	if !ok {
+		t.Fatal("expected output to be found by alias")
+	}
+	if want := uint64(2); got.ProcRecords != want {
+		t.Fatalf("expected proc records to be %d; got %d", want, got.ProcRecords)
+	}
+
+	if _, ok := mm.OutputByAlias("my_"); ok {
+		t.Fatal("expected partial alias to not match")
+	}
+}
+
+func TestMetrics_InputByAlias(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"my_cpu": {Records: 3},
+		},
+	}
+
+	got, ok := mm.InputByAlias("my_cpu")
+	if !ok {
+		t.Fatal("expected input to be found by alias")
+	}
+	if want := uint64(3); got.Records != want {
+		t.Fatalf("expected records to be %d; got %d", want, got.Records)
+	}
+
+	if _, ok := mm.InputByAlias("cpu.0"); ok {
+		t.Fatal("expected missing alias to not match")
+	}
+}
+
+func TestMetrics_FiltersOrdered(t *testing.T) {
+	mm := Metrics{
+		Filter: map[string]MetricFilter{
+			"modify.10":  {AddRecords: 10},
+			"grep.2":     {DropRecords: 2},
+			"my_lua":     {},
+			"record_mod": {},
+			"parser.1":   {},
+		},
+	}
+
+	var got []string
+	for _, f := range mm.FiltersOrdered() {
+		got = append(got, f.Name)
+	}
+
+	want := []string{"parser.1", "grep.2", "modify.10", "my_lua", "record_mod"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want filters order %v; got %v", want, got)
+	}
+}
+
+func TestMetricOutput_FailureRatio(t *testing.T) {
+	tt := []struct {
+		name string
+		in   MetricOutput
+		want float64
+	}{
+		{"empty", MetricOutput{}, 0},
+		{"no_loss", MetricOutput{ProcRecords: 10}, 0},
+		{"loss", MetricOutput{ProcRecords: 75, DroppedRecords: 25}, 0.25},
+		{"all_lost", MetricOutput{DroppedRecords: 5}, 1},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.FailureRatio(); got != tc.want {
+				t.Fatalf("want failure ratio %v; got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMetricOutput_droppedRecords(t *testing.T) {
+	var mm Metrics
+	err := json.Unmarshal([]byte(`{
+		"input": {"cpu.0": {"records": 10, "bytes": 100}},
+		"filter": {},
+		"output": {
+			"http.0": {"proc_records": 5, "proc_bytes": 50, "errors": 1, "retries": 2, "retries_failed": 1, "dropped_records": 3, "retried_records": 4},
+			"stdout.1": {"proc_records": 5, "proc_bytes": 50, "errors": 0, "retries": 0, "retries_failed": 0}
+		}
+	}`), &mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(3), mm.Output["http.0"].DroppedRecords; want != got {
+		t.Fatalf("want dropped records %d; got %d", want, got)
+	}
+
+	if want, got := uint64(0), mm.Output["stdout.1"].DroppedRecords; want != got {
+		t.Fatalf("want absent dropped records to be %d; got %d", want, got)
+	}
+}
+
+func TestMetrics_IsEmpty_HasTraffic(t *testing.T) {
+	tt := []struct {
+		name        string
+		in          Metrics
+		wantEmpty   bool
+		wantTraffic bool
+	}{
+		{"nil", Metrics{}, true, false},
+		{"zeros", Metrics{
+			Input:  map[string]MetricInput{"cpu.0": {}},
+			Output: map[string]MetricOutput{"stdout.0": {}},
+		}, false, false},
+		{"input", Metrics{Input: map[string]MetricInput{"cpu.0": {Bytes: 1}}}, false, true},
+		{"filter", Metrics{Filter: map[string]MetricFilter{"grep.0": {DropRecords: 1}}}, false, true},
+		{"output", Metrics{Output: map[string]MetricOutput{"stdout.0": {Errors: 1}}}, false, true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.IsEmpty(); tc.wantEmpty != got {
+				t.Errorf("want empty %v; got %v", tc.wantEmpty, got)
+			}
+			if got := tc.in.HasTraffic(); tc.wantTraffic != got {
+				t.Errorf("want traffic %v; got %v", tc.wantTraffic, got)
+			}
+		})
+	}
+}
+
+func TestMetricFilter_NetRecordChange(t *testing.T) {
+	var mm Metrics
+	err := json.Unmarshal([]byte(`{"filter":{"rewrite_tag.0":{"drop_records":10,"add_records":0,"emit_records":4}}}`), &mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := mm.Filter["rewrite_tag.0"]
+	if want, got := (MetricFilter{DropRecords: 10, EmitRecords: 4}), f; want != got {
+		t.Fatalf("want filter %+v; got %+v", want, got)
+	}
+
+	if want, got := int64(-6), f.NetRecordChange(); want != got {
+		t.Fatalf("want net record change %d; got %d", want, got)
+	}
+
+	f = MetricFilter{DropRecords: 1, AddRecords: 2, EmitRecords: 3}
+	if want, got := int64(4), f.NetRecordChange(); want != got {
+		t.Fatalf("want net record change %d; got %d", want, got)
+	}
+}
+
+func TestMetrics_OutputInstance(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"tail.2": {Records: 1},
+		},
+		Filter: map[string]MetricFilter{
+			"grep.0": {DropRecords: 2},
+		},
+		Output: map[string]MetricOutput{
+			"forward.2": {ProcRecords: 3},
+			"forward":   {ProcRecords: 4},
+		},
+	}
+
+	if out, ok := mm.OutputInstance("forward", 2); !ok || out.ProcRecords != 3 {
+		t.Fatalf("want forward.2 output; got %+v, %v", out, ok)
+	}
+	if in, ok := mm.InputInstance("tail", 2); !ok || in.Records != 1 {
+		t.Fatalf("want tail.2 input; got %+v, %v", in, ok)
+	}
+	if f, ok := mm.FilterInstance("grep", 0); !ok || f.DropRecords != 2 {
+		t.Fatalf("want grep.0 filter; got %+v, %v", f, ok)
+	}
+
+	if _, ok := mm.OutputInstance("forward", 0); ok {
+		t.Fatal("want no forward.0 output")
+	}
+	if _, ok := mm.OutputInstance("forward", -2); ok {
+		t.Fatal("want negative index not found")
+	}
+}
+
+func TestMetricInput_AvgRecordBytes(t *testing.T) {
+	if want, got := 0.0, (MetricInput{Bytes: 10}).AvgRecordBytes(); want != got {
+		t.Fatalf("want average %v; got %v", want, got)
+	}
+
+	prev := MetricInput{Records: 10, Bytes: 1000}
+	if want, got := 100.0, prev.AvgRecordBytes(); want != got {
+		t.Fatalf("want average %v; got %v", want, got)
+	}
+
+	curr := MetricInput{Records: 20, Bytes: 1500}
+	if want, got := 50.0, RecentAvgRecordBytes(prev, curr); want != got {
+		t.Fatalf("want recent average %v; got %v", want, got)
+	}
+
+	if want, got := 0.0, RecentAvgRecordBytes(prev, prev); want != got {
+		t.Fatalf("want recent average %v without records; got %v", want, got)
+	}
+
+	if want, got := 0.0, RecentAvgRecordBytes(curr, prev); want != got {
+		t.Fatalf("want recent average %v after a reset; got %v", want, got)
+	}
+}
+
+func TestMetricOutput_SuccessfulRecords(t *testing.T) {
+	// an output that delivered 90 records, retried a 30 records chunk
+	// three times and gave up on a 10 records chunk.
+	var mm Metrics
+	err := json.Unmarshal([]byte(`{"output":{"es.0":{"proc_records":90,"proc_bytes":9000,"errors":1,"retries":4,"retries_failed":1,"dropped_records":10,"retried_records":100}}}`), &mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := mm.Output["es.0"]
+	if want, got := uint64(90), o.SuccessfulRecords(); want != got {
+		t.Fatalf("want successful records %d; got %d", want, got)
+	}
+
+	if want, got := uint64(100), o.RetriedRecords; want != got {
+		t.Fatalf("want retried records %d; got %d", want, got)
+	}
+}
@@ -0,0 +1,72 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	semver "github.com/hashicorp/go-version"
+)
+
+type versionCheck struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// CheckVersion fetches the Fluent Bit version and fails with
+// ErrVersionTooOld if it is older than MinVersion.
+// It is a no-op without MinVersion. Requests check it lazily anyway,
+// calling it eagerly only surfaces the error at startup.
+func (c *Client) CheckVersion(ctx context.Context) error {
+	if c.MinVersion == "" {
+		return nil
+	}
+
+	c.versionCheck.mu.Lock()
+	defer c.versionCheck.mu.Unlock()
+
+	return c.checkVersionLocked(ctx)
+}
+
+// ensureVersion runs the MinVersion check once per client before the first
+// request. Failures to fetch the version are not kept, so the check is
+// retried by the next request.
+func (c *Client) ensureVersion(ctx context.Context, endpoint string) error {
+	// GET / reports the version itself.
+	if c.MinVersion == "" || endpoint == "/" {
+		return nil
+	}
+
+	c.versionCheck.mu.Lock()
+	defer c.versionCheck.mu.Unlock()
+
+	if c.versionCheck.done {
+		return c.versionCheck.err
+	}
+
+	return c.checkVersionLocked(ctx)
+}
+
+func (c *Client) checkVersionLocked(ctx context.Context) error {
+	minVersion, err := semver.NewVersion(c.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid min version %q: %w", c.MinVersion, err)
+	}
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		return fmt.Errorf("could not check fluent bit version: %w", err)
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		err = fmt.Errorf("%w: unknown version %q, want %s or newer", ErrVersionTooOld, version, c.MinVersion)
+	} else if v.LessThan(minVersion) {
+		err = fmt.Errorf("%w: %s, want %s or newer", ErrVersionTooOld, version, c.MinVersion)
+	}
+
+	c.versionCheck.done = true
+	c.versionCheck.err = err
+	return err
+}
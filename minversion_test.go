@@ -0,0 +1,73 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_MinVersion(t *testing.T) {
+	var version atomic.Value
+	version.Store("1.8.15")
+	var buildInfoRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			atomic.AddInt32(&buildInfoRequests, 1)
+			fmt.Fprintf(w, `{"fluent-bit":{"version":%q,"edition":"Community","flags":[]}}`, version.Load())
+		case "/api/v1/uptime":
+			fmt.Fprint(w, `{"uptime_sec":1}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+		MinVersion: "2.0.0",
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := client.UpTime(ctx)
+		if !errors.Is(err, ErrVersionTooOld) {
+			t.Fatalf("want error %v; got %v", ErrVersionTooOld, err)
+		}
+	}
+
+	if want, got := int32(1), atomic.LoadInt32(&buildInfoRequests); want != got {
+		t.Fatalf("want version checked %d time; got %d", want, got)
+	}
+
+	// GET / is not checked as it reports the version.
+	if _, err := client.BuildInfo(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	version.Store("2.1.8")
+	if err := client.CheckVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.UpTime(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// e.g. a downgrade.
+	version.Store("1.9.0")
+	client.Invalidate()
+	if _, err := client.UpTime(ctx); !errors.Is(err, ErrVersionTooOld) {
+		t.Fatalf("want error %v; got %v", ErrVersionTooOld, err)
+	}
+
+	client.MinVersion = "not a version"
+	if err := client.CheckVersion(ctx); err == nil || errors.Is(err, ErrVersionTooOld) {
+		t.Fatalf("want invalid min version error; got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ndjsonLine is a line written by StreamSnapshotsNDJSON.
+type ndjsonLine struct {
+	Time    time.Time `json:"time"`
+	Metrics *Metrics  `json:"metrics,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// StreamSnapshotsNDJSON scrapes Metrics right away and then every interval,
+// writing one JSON object per line to w until ctx is done.
+// Each line has the schema:
+//
+//	{"time": "<RFC 3339 scrape time>", "metrics": {<Metrics payload>}}
+//
+// or, when the scrape fails:
+//
+//	{"time": "<RFC 3339 scrape time>", "error": "<error message>"}
+//
+// Scrape errors don't stop the stream. w is flushed after each line
+// if it has a Flush method, like *bufio.Writer or http.Flusher.
+// Snapshots served by a caching proxy are timed back by their Age header,
+// like with WatchMetrics.
+// It returns ctx.Err() once ctx is done, or the first write error.
+// A non-positive interval defaults to DefaultScraperInterval.
+func (c *Client) StreamSnapshotsNDJSON(ctx context.Context, w io.Writer, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultScraperInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	enc := json.NewEncoder(w)
+	for {
+		now := time.Now()
+		var age time.Duration
+		mm, err := c.Metrics(WithAge(ctx, &age))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := ndjsonLine{Time: now.Add(-age).UTC()}
+		if err != nil {
+			line.Error = err.Error()
+		} else {
+			line.Metrics = &mm
+		}
+
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("could not write snapshot: %w", err)
+		}
+
+		if err := flush(w); err != nil {
+			return fmt.Errorf("could not flush snapshot: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package fluentbit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_StreamSnapshotsNDJSON(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	w := &cancelingWriter{cancelAfter: 3, cancel: cancel}
+	err := client.StreamSnapshotsNDJSON(ctx, w, 10*time.Millisecond)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want error %v; got %v", context.Canceled, err)
+	}
+
+	var lines []ndjsonLine
+	sc := bufio.NewScanner(&w.buf)
+	for sc.Scan() {
+		var line ndjsonLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+
+	if want, got := 3, len(lines); want != got {
+		t.Fatalf("want %d lines; got %d", want, got)
+	}
+
+	for i, line := range lines {
+		if line.Time.IsZero() {
+			t.Errorf("line %d: want time", i)
+		}
+	}
+
+	if lines[0].Metrics == nil || lines[0].Metrics.Input["cpu.0"].Records != 1 {
+		t.Errorf("want metrics on first line; got %+v", lines[0])
+	}
+	if lines[1].Metrics != nil || lines[1].Error == "" {
+		t.Errorf("want error on second line; got %+v", lines[1])
+	}
+	if lines[2].Metrics == nil {
+		t.Errorf("want metrics on third line; got %+v", lines[2])
+	}
+}
+
+func TestClient_StreamSnapshotsNDJSON_age(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Age", "30")
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// a zero interval defaults, the first line is written right away anyway.
+	before := time.Now()
+	w := &cancelingWriter{cancelAfter: 1, cancel: cancel}
+	err := client.StreamSnapshotsNDJSON(ctx, w, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want error %v; got %v", context.Canceled, err)
+	}
+
+	var line ndjsonLine
+	if err := json.Unmarshal(w.buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := before.Add(-30*time.Second), line.Time; got.Before(want.Add(-time.Second)) || got.After(time.Now().Add(-30*time.Second)) {
+		t.Fatalf("want cached snapshot time around %s; got %s", want, got)
+	}
+}
+
+// cancelingWriter cancels after being flushed cancelAfter times.
+type cancelingWriter struct {
+	buf         bytes.Buffer
+	flushes     int
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *cancelingWriter) Flush() error {
+	w.flushes++
+	if w.flushes == w.cancelAfter {
+		w.cancel()
+	}
+	return nil
+}
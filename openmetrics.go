@@ -0,0 +1,97 @@
+package fluentbit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// openMetricsHelp describes each counter field.
+var openMetricsHelp = map[string]string{
+	"records":         "Number of input records.",
+	"bytes":           "Number of input bytes.",
+	"drop_records":    "Number of records dropped by the filter.",
+	"add_records":     "Number of records added by the filter.",
+	"emit_records":    "Number of records emitted by the filter.",
+	"proc_records":    "Number of processed output records.",
+	"proc_bytes":      "Number of processed output bytes.",
+	"errors":          "Number of output errors.",
+	"retries":         "Number of output retries.",
+	"retries_failed":  "Number of failed output retries.",
+	"dropped_records": "Number of records dropped by the output.",
+	"retried_records": "Number of records retried by the output.",
+}
+
+// OpenMetricsWriter writes metrics in the OpenMetrics text format,
+// e.g. `fluentbit_output_proc_records_total{name="stdout.0"} 123`.
+// Namer, if set, names the counter families instead of PrometheusNamer,
+// with any _total suffix trimmed since only the samples carry it.
+// Labels, if set, are added to every sample after the "name" label;
+// see ValidateLabels for the valid names.
+type OpenMetricsWriter struct {
+	Labels map[string]string
+	Namer  MetricNamer
+}
+
+// WriteOpenMetrics writes m in the OpenMetrics text format,
+// e.g. `fluentbit_output_proc_records_total{name="stdout.0"} 123`.
+// Each counter family is named like Fluent Bit's own Prometheus endpoint
+// without the _total suffix, which only its samples carry,
+// the byte counters declare their unit and the exposition ends with "# EOF".
+func (m Metrics) WriteOpenMetrics(w io.Writer) error {
+	ow := OpenMetricsWriter{}
+	return ow.Write(w, m)
+}
+
+// Write writes m to w.
+func (ow *OpenMetricsWriter) Write(w io.Writer, m Metrics) error {
+	return ow.write(w, m, time.Time{})
+}
+
+// write is Write adding a _created sample
+// to every counter unless created is zero.
+func (ow *OpenMetricsWriter) write(w io.Writer, m Metrics, created time.Time) error {
+	if err := ValidateLabels(ow.Labels); err != nil {
+		return err
+	}
+
+	var static strings.Builder
+	for _, l := range sortedLabels(ow.Labels) {
+		fmt.Fprintf(&static, ",%s=\"%s\"", l[0], openMetricsLabelReplacer.Replace(l[1]))
+	}
+
+	namer := namerOrDefault(ow.Namer, PrometheusNamer)
+	var families []string
+	samples := map[string][]counter{}
+	for _, c := range m.counters() {
+		family := strings.TrimSuffix(namer.name(c), "_total")
+		if _, ok := samples[family]; !ok {
+			families = append(families, family)
+		}
+		samples[family] = append(samples[family], c)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		cc := samples[family]
+		fmt.Fprintf(bw, "# TYPE %s counter\n", family)
+		if strings.HasSuffix(family, "_bytes") {
+			fmt.Fprintf(bw, "# UNIT %s bytes\n", family)
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", family, openMetricsHelp[cc[0].field])
+		for _, c := range cc {
+			labels := fmt.Sprintf("{name=\"%s\"%s}", openMetricsLabelReplacer.Replace(c.plugin), static.String())
+			fmt.Fprintf(bw, "%s_total%s %d\n", family, labels, c.value)
+			if !created.IsZero() {
+				fmt.Fprintf(bw, "%s_created%s %d\n", family, labels, created.Unix())
+			}
+		}
+	}
+	bw.WriteString("# EOF\n")
+
+	return bw.Flush()
+}
+
+var openMetricsLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
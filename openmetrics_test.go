@@ -0,0 +1,69 @@
+package fluentbit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMetrics_WriteOpenMetrics(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0":  {Records: 1, Bytes: 10},
+			"tail.0": {Records: 2, Bytes: 20},
+		},
+		Filter: map[string]MetricFilter{
+			"grep.0": {DropRecords: 3},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0":        {ProcRecords: 123, ProcBytes: 1230, Errors: 1, Retries: 2, RetriesFailed: 1},
+			`my "out"` + "\n": {DroppedRecords: 4},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := mm.WriteOpenMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ioutil.ReadFile("testdata/openmetrics.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); string(want) != got {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestOpenMetricsWriter(t *testing.T) {
+	mm := Metrics{
+		Output: map[string]MetricOutput{"stdout.0": {ProcRecords: 123}},
+	}
+
+	ow := OpenMetricsWriter{
+		Labels: map[string]string{"tenant": "acme", "cluster": `eu-"1"`},
+		Namer: func(section, plugin, field string) string {
+			return "fb_" + section + "_" + field
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ow.Write(&buf, mm); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `fb_output_proc_records_total{name="stdout.0",cluster="eu-\"1\"",tenant="acme"} 123`
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("want %q in:\n%s", want, got)
+	}
+	if want, got := "# TYPE fb_output_proc_records counter", buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("want %q in:\n%s", want, got)
+	}
+
+	ow.Labels = map[string]string{"name": "x"}
+	if err := ow.Write(&buf, mm); err == nil {
+		t.Fatal("want error for label colliding with the plugin name")
+	}
+}
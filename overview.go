@@ -0,0 +1,44 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+)
+
+// InputOverview joins what an input ingested with what it has buffered.
+type InputOverview struct {
+	// Metrics is nil when the input is missing from /api/v1/metrics.
+	Metrics *MetricInput
+	// Storage is nil when the input is missing from /api/v1/storage
+	// or storage metrics are disabled.
+	Storage *PluginStorage
+}
+
+// InputOverview fetches Metrics and StorageMetrics and joins them
+// by input name. Inputs present in only one of them are kept with
+// the other side nil. Storage metrics being disabled is not an error,
+// every input just has a nil Storage.
+func (c *Client) InputOverview(ctx context.Context) (map[string]InputOverview, error) {
+	mm, err := c.Metrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sm, err := c.StorageMetrics(ctx)
+	if err != nil && !errors.Is(err, ErrEndpointNotFound) {
+		return nil, err
+	}
+
+	out := map[string]InputOverview{}
+	for name, in := range mm.Input {
+		in := in
+		out[name] = InputOverview{Metrics: &in}
+	}
+	for name, p := range sm.InputChunks {
+		p := p
+		o := out[name]
+		o.Storage = &p
+		out[name] = o
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_InputOverview(t *testing.T) {
+	storageEnabled := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/metrics":
+			fmt.Fprint(w, `{"input":{"cpu.0":{"records":10,"bytes":100},"tail.1":{"records":5,"bytes":50}},"filter":{},"output":{}}`)
+		case "/api/v1/storage":
+			if !storageEnabled {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, `{"storage_layer":{"chunks":{"total_chunks":3}},"input_chunks":{"cpu.0":{"chunks":{"total":1}},"storage_backlog.2":{"chunks":{"total":2}}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	got, err := client.InputOverview(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 3; len(got) != want {
+		t.Fatalf("want %d inputs; got %+v", want, got)
+	}
+
+	if o := got["cpu.0"]; o.Metrics == nil || o.Metrics.Records != 10 || o.Storage == nil || o.Storage.TotalChunks() != 1 {
+		t.Errorf("want cpu.0 metrics and storage; got %+v", o)
+	}
+	if o := got["tail.1"]; o.Metrics == nil || o.Storage != nil {
+		t.Errorf("want tail.1 metrics only; got %+v", o)
+	}
+	if o := got["storage_backlog.2"]; o.Metrics != nil || o.Storage == nil {
+		t.Errorf("want storage_backlog.2 storage only; got %+v", o)
+	}
+
+	storageEnabled = false
+	got, err = client.InputOverview(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o := got["cpu.0"]; o.Metrics == nil || o.Storage != nil {
+		t.Errorf("want cpu.0 metrics only with storage disabled; got %+v", o)
+	}
+}
@@ -0,0 +1,103 @@
+package fluentbit
+
+import (
+	"context"
+	"strings"
+)
+
+// Plugins lists plugin instance names grouped by kind.
+type Plugins struct {
+	Inputs  []string
+	Filters []string
+	Outputs []string
+}
+
+// Plugins returns the sorted plugin instance names found in m,
+// including the ones with zero traffic.
+func (m Metrics) Plugins() Plugins {
+	return Plugins{
+		Inputs:  sortedKeys(m.Input),
+		Filters: sortedKeys(m.Filter),
+		Outputs: sortedKeys(m.Output),
+	}
+}
+
+// LoadedPlugins returns the plugins loaded by the running Fluent Bit,
+// as derived from the metrics keys.
+// It reflects the loaded plugins, not the config: a configured plugin
+// that failed to initialize is missing from the result.
+func (c *Client) LoadedPlugins(ctx context.Context) (Plugins, error) {
+	mm, err := c.Metrics(ctx)
+	if err != nil {
+		return Plugins{}, err
+	}
+
+	return mm.Plugins(), nil
+}
+
+// Missing returns the expected plugins that are not loaded according to m.
+// An expected name matches a loaded instance with the exact same name,
+// like "forward.2" or an alias, or else any instance of that plugin type,
+// so "forward" matches "forward.0". Each loaded instance satisfies
+// a single expectation, so listing "forward" twice expects two instances.
+func (m Metrics) Missing(expected Plugins) Plugins {
+	loaded := m.Plugins()
+	return Plugins{
+		Inputs:  missingPlugins(expected.Inputs, loaded.Inputs),
+		Filters: missingPlugins(expected.Filters, loaded.Filters),
+		Outputs: missingPlugins(expected.Outputs, loaded.Outputs),
+	}
+}
+
+func missingPlugins(expected, loaded []string) []string {
+	used := make(map[string]bool, len(loaded))
+	var missing []string
+
+	// exact names first so they aren't taken by a type match.
+	var byType []string
+	for _, name := range expected {
+		if containsString(loaded, name) && !used[name] {
+			used[name] = true
+			continue
+		}
+		byType = append(byType, name)
+	}
+
+	for _, typ := range byType {
+		found := false
+		for _, name := range loaded {
+			if used[name] || pluginType(name) != typ {
+				continue
+			}
+			if _, ok := pluginIndex(name); !ok {
+				continue
+			}
+
+			used[name] = true
+			found = true
+			break
+		}
+		if !found {
+			missing = append(missing, typ)
+		}
+	}
+
+	return missing
+}
+
+// pluginType returns the type of a "type.index" plugin instance name.
+func pluginType(name string) string {
+	if _, ok := pluginIndex(name); !ok {
+		return name
+	}
+	return name[:strings.LastIndexByte(name, '.')]
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClient_LoadedPlugins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"input": {"tail.1": {"records": 0, "bytes": 0}, "cpu.0": {"records": 0, "bytes": 0}},
+			"filter": {"grep.0": {"drop_records": 0, "add_records": 0}},
+			"output": {"stdout.0": {"proc_records": 0, "proc_bytes": 0, "errors": 0, "retries": 0, "retries_failed": 0}}
+		}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	got, err := client.LoadedPlugins(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Plugins{
+		Inputs:  []string{"cpu.0", "tail.1"},
+		Filters: []string{"grep.0"},
+		Outputs: []string{"stdout.0"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want plugins %+v; got %+v", want, got)
+	}
+}
+
+func TestMetrics_Missing(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"tail.0": {},
+			"tail.1": {},
+			"my_cpu": {},
+		},
+		Output: map[string]MetricOutput{
+			"forward.0": {},
+			"es.1":      {},
+		},
+	}
+
+	got := mm.Missing(Plugins{
+		Inputs:  []string{"tail", "tail.0", "my_cpu", "mem"},
+		Filters: []string{"grep"},
+		Outputs: []string{"forward", "forward", "es.1", "http.3"},
+	})
+
+	want := Plugins{
+		Inputs:  []string{"mem"},
+		Filters: []string{"grep"},
+		Outputs: []string{"forward", "http.3"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want missing plugins %+v; got %+v", want, got)
+	}
+
+	if got := mm.Missing(mm.Plugins()); !reflect.DeepEqual(Plugins{}, got) {
+		t.Fatalf("want no missing plugins; got %+v", got)
+	}
+}
@@ -0,0 +1,371 @@
+package fluentbit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PromMetric is a single sample parsed from the Prometheus text exposition format.
+type PromMetric struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Type is the metric family type declared by a "# TYPE" comment,
+	// e.g. "counter", "gauge" or "histogram". Empty if undeclared.
+	Type string
+}
+
+// PrometheusMetrics fetches and parses GET /api/v2/metrics/prometheus
+func (c *Client) PrometheusMetrics(ctx context.Context) ([]PromMetric, error) {
+	return c.prometheusMetrics(ctx, c.RetryNotFound)
+}
+
+func (c *Client) prometheusMetrics(ctx context.Context, retryNotFound bool) ([]PromMetric, error) {
+	var out []PromMetric
+	return out, c.fetch(ctx, "/api/v2/metrics/prometheus", retryNotFound, func(r io.Reader) error {
+		var err error
+		out, err = ParsePrometheus(r)
+		return err
+	})
+}
+
+// PrometheusMetricsStream fetches GET /api/v2/metrics/prometheus like
+// PrometheusMetrics but calls fn for each sample as it is parsed,
+// so the body, gunzipped if needed, is never held in memory at once.
+// Returning an error from fn stops parsing, and the error is returned as is.
+func (c *Client) PrometheusMetricsStream(ctx context.Context, fn func(PromMetric) error) error {
+	return c.fetch(ctx, "/api/v2/metrics/prometheus", c.RetryNotFound, func(r io.Reader) error {
+		return parsePrometheus(r, fn)
+	})
+}
+
+// ParsePrometheus parses samples in the Prometheus text exposition format.
+// Comments other than "# TYPE" and blank lines are ignored.
+func ParsePrometheus(r io.Reader) ([]PromMetric, error) {
+	var out []PromMetric
+	err := parsePrometheus(r, func(m PromMetric) error {
+		out = append(out, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func parsePrometheus(r io.Reader, fn func(PromMetric) error) error {
+	types := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) == 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		m, err := parsePromLine(line)
+		if err != nil {
+			return fmt.Errorf("could not parse prometheus line %d: %w", n, err)
+		}
+
+		m.Type = promType(types, m.Name)
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read prometheus metrics: %w", err)
+	}
+
+	return nil
+}
+
+func promType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if family := strings.TrimSuffix(name, suffix); family != name {
+			if t, ok := types[family]; ok {
+				return t
+			}
+		}
+	}
+
+	return ""
+}
+
+func parsePromLine(line string) (PromMetric, error) {
+	var m PromMetric
+
+	end := strings.IndexAny(line, "{ \t")
+	if end == -1 {
+		return m, errors.New("missing value")
+	}
+
+	m.Name = line[:end]
+	if m.Name == "" {
+		return m, errors.New("missing metric name")
+	}
+
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		labels, n, err := parsePromLabels(rest)
+		if err != nil {
+			return m, err
+		}
+
+		m.Labels = labels
+		rest = rest[n:]
+	}
+
+	// value is optionally followed by a timestamp which is ignored.
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return m, fmt.Errorf("invalid sample %q", rest)
+	}
+
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return m, fmt.Errorf("invalid value %q: %w", fields[0], err)
+	}
+
+	m.Value = v
+	return m, nil
+}
+
+// parsePromLabels parses a label set starting at "{"
+// and returns the number of bytes consumed including the closing "}".
+func parsePromLabels(s string) (map[string]string, int, error) {
+	labels := map[string]string{}
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+
+		if i >= len(s) {
+			return nil, 0, errors.New("unterminated label set")
+		}
+
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq == -1 {
+			return nil, 0, errors.New("missing label value")
+		}
+
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, fmt.Errorf("unquoted value for label %q", name)
+		}
+		i++
+
+		var value strings.Builder
+		for {
+			if i >= len(s) {
+				return nil, 0, fmt.Errorf("unterminated value for label %q", name)
+			}
+
+			ch := s[i]
+			if ch == '"' {
+				i++
+				break
+			}
+
+			if ch == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				i++
+				continue
+			}
+
+			value.WriteByte(ch)
+			i++
+		}
+
+		labels[name] = value.String()
+	}
+}
+
+// Fluent Bit Prometheus metric names mapped into the typed structs.
+// Plugin samples are keyed by their "name" label.
+//
+//	fluentbit_uptime                       UpTime.UpTimeSec
+//	fluentbit_input_records_total          MetricInput.Records
+//	fluentbit_input_bytes_total            MetricInput.Bytes
+//	fluentbit_filter_drop_records_total    MetricFilter.DropRecords
+//	fluentbit_filter_add_records_total     MetricFilter.AddRecords
+//	fluentbit_filter_emit_records_total    MetricFilter.EmitRecords
+//	fluentbit_output_proc_records_total    MetricOutput.ProcRecords
+//	fluentbit_output_proc_bytes_total      MetricOutput.ProcBytes
+//	fluentbit_output_errors_total          MetricOutput.Errors
+//	fluentbit_output_retries_total         MetricOutput.Retries
+//	fluentbit_output_retries_failed_total  MetricOutput.RetriesFailed
+//	fluentbit_output_dropped_records_total MetricOutput.DroppedRecords
+//	fluentbit_output_retried_records_total MetricOutput.RetriedRecords
+const (
+	promUpTime              = "fluentbit_uptime"
+	promInputRecords        = "fluentbit_input_records_total"
+	promInputBytes          = "fluentbit_input_bytes_total"
+	promFilterDropRecords   = "fluentbit_filter_drop_records_total"
+	promFilterAddRecords    = "fluentbit_filter_add_records_total"
+	promFilterEmitRecords   = "fluentbit_filter_emit_records_total"
+	promOutputProcRecords   = "fluentbit_output_proc_records_total"
+	promOutputProcBytes     = "fluentbit_output_proc_bytes_total"
+	promOutputErrors        = "fluentbit_output_errors_total"
+	promOutputRetries       = "fluentbit_output_retries_total"
+	promOutputRetriesFailed = "fluentbit_output_retries_failed_total"
+	promOutputDropped       = "fluentbit_output_dropped_records_total"
+	promOutputRetried       = "fluentbit_output_retried_records_total"
+)
+
+// MetricsFromPrometheus maps the well-known Fluent Bit metric families
+// into Metrics, so consumers can use the same type regardless of which
+// endpoint is enabled. Unknown families are ignored.
+func MetricsFromPrometheus(samples []PromMetric) Metrics {
+	mm := Metrics{
+		Input:  map[string]MetricInput{},
+		Filter: map[string]MetricFilter{},
+		Output: map[string]MetricOutput{},
+	}
+
+	for _, s := range samples {
+		name := s.Labels["name"]
+		if name == "" {
+			continue
+		}
+
+		v := promCounter(s.Value)
+		switch s.Name {
+		case promInputRecords:
+			in := mm.Input[name]
+			in.Records = v
+			mm.Input[name] = in
+		case promInputBytes:
+			in := mm.Input[name]
+			in.Bytes = v
+			mm.Input[name] = in
+		case promFilterDropRecords:
+			f := mm.Filter[name]
+			f.DropRecords = v
+			mm.Filter[name] = f
+		case promFilterAddRecords:
+			f := mm.Filter[name]
+			f.AddRecords = v
+			mm.Filter[name] = f
+		case promFilterEmitRecords:
+			f := mm.Filter[name]
+			f.EmitRecords = v
+			mm.Filter[name] = f
+		case promOutputProcRecords:
+			out := mm.Output[name]
+			out.ProcRecords = v
+			mm.Output[name] = out
+		case promOutputProcBytes:
+			out := mm.Output[name]
+			out.ProcBytes = v
+			mm.Output[name] = out
+		case promOutputErrors:
+			out := mm.Output[name]
+			out.Errors = v
+			mm.Output[name] = out
+		case promOutputRetries:
+			out := mm.Output[name]
+			out.Retries = v
+			mm.Output[name] = out
+		case promOutputRetriesFailed:
+			out := mm.Output[name]
+			out.RetriesFailed = v
+			mm.Output[name] = out
+		case promOutputDropped:
+			out := mm.Output[name]
+			out.DroppedRecords = v
+			mm.Output[name] = out
+		case promOutputRetried:
+			out := mm.Output[name]
+			out.RetriedRecords = v
+			mm.Output[name] = out
+		}
+	}
+
+	if upstream := upstreamsFromPrometheus(samples); len(upstream) != 0 {
+		mm.Upstream = upstream
+	}
+
+	return mm
+}
+
+// UpTimeFromPrometheus returns the uptime reported by the fluentbit_uptime gauge.
+func UpTimeFromPrometheus(samples []PromMetric) (UpTime, bool) {
+	for _, s := range samples {
+		if s.Name != promUpTime {
+			continue
+		}
+
+		sec := promCounter(s.Value)
+		return UpTime{
+			UpTimeSec: sec,
+			UpTimeHr:  upTimeHr(sec),
+		}, true
+	}
+
+	return UpTime{}, false
+}
+
+// promCounter converts a sample value into a counter,
+// clamping negative and NaN values to zero.
+func promCounter(v float64) uint64 {
+	if math.IsNaN(v) || v <= 0 {
+		return 0
+	}
+
+	if v >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+
+	return uint64(v)
+}
+
+// upTimeHr mimics the human readable uptime Fluent Bit reports.
+func upTimeHr(sec uint64) string {
+	plural := func(n uint64, unit string) string {
+		if n > 1 {
+			return fmt.Sprintf("%d %ss", n, unit)
+		}
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+
+	return fmt.Sprintf("Fluent Bit has been running:  %s, %s, %s and %s",
+		plural(sec/86400, "day"),
+		plural(sec%86400/3600, "hour"),
+		plural(sec%3600/60, "minute"),
+		plural(sec%60, "second"),
+	)
+}
@@ -0,0 +1,258 @@
+package fluentbit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrometheus(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		got, err := ParsePrometheus(strings.NewReader(`# TYPE test_total counter
+test_total{name="a.0",path="C:\\dir \"x\"\n"} 12 1676545813000
+test_plain 1.5e3
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []PromMetric{
+			{
+				Name:   "test_total",
+				Labels: map[string]string{"name": "a.0", "path": "C:\\dir \"x\"\n"},
+				Value:  12,
+				Type:   "counter",
+			},
+			{
+				Name:  "test_plain",
+				Value: 1500,
+			},
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("want samples %+v; got %+v", want, got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, line := range []string{
+			`test_total`,
+			`test_total{name="a.0"`,
+			`test_total{name=a} 1`,
+			`test_total nope`,
+		} {
+			if _, err := ParsePrometheus(strings.NewReader(line)); err == nil {
+				t.Errorf("want error parsing %q", line)
+			}
+		}
+	})
+}
+
+func TestMetricsFromPrometheus(t *testing.T) {
+	f, err := os.Open("testdata/prometheus_v2.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	samples, err := ParsePrometheus(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 40, Bytes: 10240},
+		},
+		Filter: map[string]MetricFilter{
+			"grep.0": {DropRecords: 3},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 38, ProcBytes: 9728, Errors: 1, Retries: 2},
+		},
+	}
+	if got := MetricsFromPrometheus(samples); !reflect.DeepEqual(want, got) {
+		t.Fatalf("want metrics %+v; got %+v", want, got)
+	}
+
+	up, ok := UpTimeFromPrometheus(samples)
+	if !ok {
+		t.Fatal("want uptime")
+	}
+
+	wantUp := UpTime{
+		UpTimeSec: 3661,
+		UpTimeHr:  "Fluent Bit has been running:  0 day, 1 hour, 1 minute and 1 second",
+	}
+	if wantUp != up {
+		t.Fatalf("want uptime %+v; got %+v", wantUp, up)
+	}
+}
+
+func TestClient_PrometheusMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/metrics/prometheus" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, "testdata/prometheus_v2.txt")
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	samples, err := client.PrometheusMetrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 10, len(samples); want != got {
+		t.Fatalf("want %d samples; got %d", want, got)
+	}
+}
+
+func TestClient_PrometheusMetricsStream(t *testing.T) {
+	srv := httptest.NewServer(gzipPrometheusHandler(t, 100))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	var n int
+	err := client.PrometheusMetricsStream(ctx, func(m PromMetric) error {
+		if want, got := "counter", m.Type; want != got {
+			return fmt.Errorf("want type %q; got %q", want, got)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 100, n; want != got {
+		t.Fatalf("want %d samples; got %d", want, got)
+	}
+
+	errStop := errors.New("stop")
+	n = 0
+	err = client.PrometheusMetricsStream(ctx, func(m PromMetric) error {
+		n++
+		if n == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("want error %v; got %v", errStop, err)
+	}
+
+	if want, got := 3, n; want != got {
+		t.Fatalf("want parsing stopped after %d samples; got %d", want, got)
+	}
+}
+
+// gzipPrometheusHandler serves n gzipped fluentbit_output_proc_records_total samples.
+func gzipPrometheusHandler(tb testing.TB, n int) http.Handler {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprintln(gz, "# TYPE fluentbit_output_proc_records_total counter")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(gz, "fluentbit_output_proc_records_total{name=\"http.%d\"} %d\n", i, i)
+	}
+	if err := gz.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+func BenchmarkClient_PrometheusMetrics(b *testing.B) {
+	srv := httptest.NewServer(gzipPrometheusHandler(b, 5000))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx := context.Background()
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.PrometheusMetrics(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := client.PrometheusMetricsStream(ctx, func(PromMetric) error { return nil })
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestClient_Metrics_prometheusFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/metrics/prometheus" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, "testdata/prometheus_v2.txt")
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:         srv.Client(),
+		BaseURL:            srv.URL,
+		PrometheusFallback: true,
+	}
+
+	mm, err := client.Metrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(40), mm.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want input records %d; got %d", want, got)
+	}
+
+	if want, got := uint64(38), mm.Output["stdout.0"].ProcRecords; want != got {
+		t.Fatalf("want output proc records %d; got %d", want, got)
+	}
+}
+
+func TestMetricsFromPrometheus_emitRecords(t *testing.T) {
+	samples, err := ParsePrometheus(strings.NewReader(`
+fluentbit_filter_drop_records_total{name="rewrite_tag.0"} 10
+fluentbit_filter_emit_records_total{name="rewrite_tag.0"} 4
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := MetricFilter{DropRecords: 10, EmitRecords: 4}
+	if got := MetricsFromPrometheus(samples).Filter["rewrite_tag.0"]; want != got {
+		t.Fatalf("want filter %+v; got %+v", want, got)
+	}
+}
@@ -0,0 +1,147 @@
+package fluentbit
+
+import "time"
+
+// MetricsRate holds per-second rates computed from two Metrics snapshots.
+// Maps keyed by plugin instance name.
+type MetricsRate struct {
+	Input  map[string]InputRate
+	Filter map[string]FilterRate
+	Output map[string]OutputRate
+}
+
+type InputRate struct {
+	Records float64
+	Bytes   float64
+}
+
+type FilterRate struct {
+	DropRecords float64
+	AddRecords  float64
+	EmitRecords float64
+}
+
+type OutputRate struct {
+	ProcRecords   float64
+	ProcBytes     float64
+	Errors        float64
+	Retries       float64
+	RetriesFailed float64
+}
+
+// Rate computes the per-second rates between two snapshots taken elapsed apart.
+// Plugins missing from prev are skipped, as are counters that decreased
+// since a reset makes the difference meaningless.
+// A non-positive elapsed yields empty rates.
+func Rate(prev, curr Metrics, elapsed time.Duration) MetricsRate {
+	out := MetricsRate{
+		Input:  map[string]InputRate{},
+		Filter: map[string]FilterRate{},
+		Output: map[string]OutputRate{},
+	}
+
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return out
+	}
+
+	for name, c := range curr.Input {
+		p, ok := prev.Input[name]
+		if !ok {
+			continue
+		}
+
+		out.Input[name] = InputRate{
+			Records: counterRate(p.Records, c.Records, secs),
+			Bytes:   counterRate(p.Bytes, c.Bytes, secs),
+		}
+	}
+
+	for name, c := range curr.Filter {
+		p, ok := prev.Filter[name]
+		if !ok {
+			continue
+		}
+
+		out.Filter[name] = FilterRate{
+			DropRecords: counterRate(p.DropRecords, c.DropRecords, secs),
+			AddRecords:  counterRate(p.AddRecords, c.AddRecords, secs),
+			EmitRecords: counterRate(p.EmitRecords, c.EmitRecords, secs),
+		}
+	}
+
+	for name, c := range curr.Output {
+		p, ok := prev.Output[name]
+		if !ok {
+			continue
+		}
+
+		out.Output[name] = OutputRate{
+			ProcRecords:   counterRate(p.ProcRecords, c.ProcRecords, secs),
+			ProcBytes:     counterRate(p.ProcBytes, c.ProcBytes, secs),
+			Errors:        counterRate(p.Errors, c.Errors, secs),
+			Retries:       counterRate(p.Retries, c.Retries, secs),
+			RetriesFailed: counterRate(p.RetriesFailed, c.RetriesFailed, secs),
+		}
+	}
+
+	return out
+}
+
+// ErrorsByPluginType computes the per-second output error rate between two
+// snapshots taken elapsed apart, summed by output plugin type, e.g. "es"
+// for "es.0" and "es.1". Aliased outputs are grouped under their alias.
+// Like Rate, it skips outputs missing from either snapshot
+// and counts reset error counters as zero.
+// A non-positive elapsed yields an empty map.
+func ErrorsByPluginType(prev, curr Metrics, elapsed time.Duration) map[string]float64 {
+	out := map[string]float64{}
+
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return out
+	}
+
+	for name, c := range curr.Output {
+		p, ok := prev.Output[name]
+		if !ok {
+			continue
+		}
+
+		out[pluginType(name)] += counterRate(p.Errors, c.Errors, secs)
+	}
+
+	return out
+}
+
+func counterRate(prev, curr uint64, secs float64) float64 {
+	if reset := curr < prev; reset {
+		return 0
+	}
+
+	return float64(curr-prev) / secs
+}
+
+// AverageRates computes the lifetime average per-second rates of m,
+// dividing each counter by the uptime u reported along with it.
+// It gives a first idea of the throughput from a single scrape, but it is
+// an average since start, not the current rate: use Rate once two
+// snapshots are available. A zero uptime yields empty rates.
+func AverageRates(m Metrics, u UpTime) MetricsRate {
+	start := Metrics{
+		Input:  map[string]MetricInput{},
+		Filter: map[string]MetricFilter{},
+		Output: map[string]MetricOutput{},
+	}
+	for name := range m.Input {
+		start.Input[name] = MetricInput{}
+	}
+	for name := range m.Filter {
+		start.Filter[name] = MetricFilter{}
+	}
+	for name := range m.Output {
+		start.Output[name] = MetricOutput{}
+	}
+
+	return Rate(start, m, time.Duration(u.UpTimeSec)*time.Second)
+}
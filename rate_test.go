@@ -0,0 +1,111 @@
+package fluentbit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	prev := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 10, Bytes: 100},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 10, ProcBytes: 100, Errors: 4},
+		},
+	}
+	curr := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 30, Bytes: 300},
+			"mem.1": {Records: 5, Bytes: 50},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 30, ProcBytes: 300, Errors: 0},
+		},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		got := Rate(prev, curr, 2*time.Second)
+		want := MetricsRate{
+			Input: map[string]InputRate{
+				"cpu.0": {Records: 10, Bytes: 100},
+			},
+			Filter: map[string]FilterRate{},
+			Output: map[string]OutputRate{
+				"stdout.0": {ProcRecords: 10, ProcBytes: 100},
+			},
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("want rate %+v; got %+v", want, got)
+		}
+	})
+
+	t.Run("zero_elapsed", func(t *testing.T) {
+		got := Rate(prev, curr, 0)
+		if len(got.Input) != 0 || len(got.Output) != 0 {
+			t.Fatalf("want empty rate; got %+v", got)
+		}
+	})
+}
+
+func TestErrorsByPluginType(t *testing.T) {
+	prev := Metrics{
+		Output: map[string]MetricOutput{
+			"es.0":      {Errors: 10},
+			"es.1":      {Errors: 5},
+			"forward.0": {Errors: 100},
+			"gone.0":    {Errors: 1},
+		},
+	}
+	curr := Metrics{
+		Output: map[string]MetricOutput{
+			"es.0":      {Errors: 30},
+			"es.1":      {Errors: 25},
+			"forward.0": {Errors: 2}, // restarted.
+			"new.0":     {Errors: 8},
+		},
+	}
+
+	got := ErrorsByPluginType(prev, curr, 10*time.Second)
+	want := map[string]float64{
+		"es":      4,
+		"forward": 0,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want errors by plugin type %+v; got %+v", want, got)
+	}
+
+	if got := ErrorsByPluginType(prev, curr, 0); len(got) != 0 {
+		t.Fatalf("want empty errors by plugin type for zero elapsed; got %+v", got)
+	}
+}
+
+func TestAverageRates(t *testing.T) {
+	m := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 600, Bytes: 6000},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 300, Errors: 6},
+		},
+	}
+
+	got := AverageRates(m, UpTime{UpTimeSec: 60})
+	want := MetricsRate{
+		Input: map[string]InputRate{
+			"cpu.0": {Records: 10, Bytes: 100},
+		},
+		Filter: map[string]FilterRate{},
+		Output: map[string]OutputRate{
+			"stdout.0": {ProcRecords: 5, Errors: 0.1},
+		},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %+v; got %+v", want, got)
+	}
+
+	if got := AverageRates(m, UpTime{}); len(got.Input) != 0 || len(got.Output) != 0 {
+		t.Fatalf("want empty rates with zero uptime; got %+v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package fluentbit
+
+import (
+	"sync"
+	"time"
+)
+
+// RateTracker keeps the previous snapshot to compute rates incrementally,
+// the stateful companion of Rate. Update is meant to be driven by a single
+// goroutine while Last can be called concurrently.
+// The zero value is ready to use.
+type RateTracker struct {
+	mu     sync.RWMutex
+	prev   Metrics
+	prevAt time.Time
+	last   MetricsRate
+	primed bool
+}
+
+// Update stores m, taken at the given time, and returns the rates since the
+// previous Update. The first Update has nothing to compare with and returns
+// empty rates. So does an Update where any counter went down, as happens
+// when Fluent Bit restarts or hot reloads; m becomes the new baseline.
+func (t *RateTracker) Update(m Metrics, at time.Time) MetricsRate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rate := MetricsRate{
+		Input:  map[string]InputRate{},
+		Filter: map[string]FilterRate{},
+		Output: map[string]OutputRate{},
+	}
+	if t.primed && len(ClassifyReset(t.prev, m, false).Anomalous) == 0 {
+		rate = Rate(t.prev, m, at.Sub(t.prevAt))
+	}
+
+	t.prev, t.prevAt, t.primed = m, at, true
+	t.last = rate
+	return rate
+}
+
+// Last returns the rates computed by the latest Update.
+// They are shared with other callers and must not be modified.
+func (t *RateTracker) Last() MetricsRate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.last
+}
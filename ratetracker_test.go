@@ -0,0 +1,67 @@
+package fluentbit
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateTracker(t *testing.T) {
+	snapshot := func(records uint64) Metrics {
+		return Metrics{
+			Input: map[string]MetricInput{
+				"cpu.0": {Records: records},
+			},
+		}
+	}
+	empty := MetricsRate{
+		Input:  map[string]InputRate{},
+		Filter: map[string]FilterRate{},
+		Output: map[string]OutputRate{},
+	}
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var tracker RateTracker
+
+	if got := tracker.Update(snapshot(10), start); !reflect.DeepEqual(empty, got) {
+		t.Fatalf("want empty rate on first update; got %+v", got)
+	}
+
+	got := tracker.Update(snapshot(30), start.Add(2*time.Second))
+	if want, got := 10.0, got.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want records rate %v; got %v", want, got)
+	}
+
+	if want, got := 10.0, tracker.Last().Input["cpu.0"].Records; want != got {
+		t.Fatalf("want last records rate %v; got %v", want, got)
+	}
+
+	// restarted: counters start from zero again.
+	if got := tracker.Update(snapshot(4), start.Add(4*time.Second)); !reflect.DeepEqual(empty, got) {
+		t.Fatalf("want empty rate on restart; got %+v", got)
+	}
+
+	got = tracker.Update(snapshot(8), start.Add(6*time.Second))
+	if want, got := 2.0, got.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want records rate since restart %v; got %v", want, got)
+	}
+}
+
+func TestRateTracker_concurrent(t *testing.T) {
+	var tracker RateTracker
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		at := time.Now()
+		for i := uint64(0); i < 100; i++ {
+			tracker.Update(Metrics{Input: map[string]MetricInput{"cpu.0": {Records: i}}}, at.Add(time.Duration(i)*time.Second))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = tracker.Last().Input["cpu.0"]
+	}
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errNoInputs = errors.New("no input metrics yet")
+
+// WaitReady polls Fluent Bit every pollInterval until both BuildInfo and Metrics
+// succeed and at least one input reports metrics, or until ctx is done.
+// It is a replacement for sleeping a fixed duration after startup.
+// A non-positive pollInterval defaults to DefaultHTTPRetryBackoff.
+func (c *Client) WaitReady(ctx context.Context, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultHTTPRetryBackoff
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		err := c.ready(ctx)
+		if err == nil {
+			return nil
+		}
+
+		// keep the reason of the last complete poll
+		// rather than the cancellation of an ongoing one.
+		if ctx.Err() == nil || lastErr == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("fluent bit not ready: %w", lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) ready(ctx context.Context) error {
+	if _, err := c.BuildInfo(ctx); err != nil {
+		return err
+	}
+
+	mm, err := c.Metrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(mm.Input) == 0 {
+		return errNoInputs
+	}
+
+	return nil
+}
+
+// WaitForOutputRecords polls Metrics every pollInterval until the named output
+// has processed at least atLeast records, or until ctx is done.
+// On timeout, the error includes the last observed value.
+// A non-positive pollInterval defaults to DefaultHTTPRetryBackoff.
+func (c *Client) WaitForOutputRecords(ctx context.Context, name string, atLeast uint64, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultHTTPRetryBackoff
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	last := "no metrics"
+	for {
+		mm, err := c.Metrics(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				last = err.Error()
+			}
+		default:
+			out, ok := mm.Output[name]
+			if !ok {
+				last = "output not found"
+				break
+			}
+
+			if out.ProcRecords >= atLeast {
+				return nil
+			}
+
+			last = fmt.Sprintf("%d records", out.ProcRecords)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("output %q did not process %d records: last observed %s: %w", name, atLeast, last, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
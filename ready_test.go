@@ -0,0 +1,140 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_WaitReady(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var polls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				fmt.Fprint(w, `{"fluent-bit":{"version":"1.8.0","edition":"Community","flags":[]}}`)
+			case "/api/v1/metrics":
+				if atomic.AddInt32(&polls, 1) < 3 {
+					fmt.Fprint(w, `{"input":{},"output":{}}`)
+					return
+				}
+				fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"output":{}}`)
+			}
+		}))
+		defer srv.Close()
+
+		client := &Client{
+			HTTPClient: srv.Client(),
+			BaseURL:    srv.URL,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.WaitReady(ctx, 10*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				fmt.Fprint(w, `{"fluent-bit":{"version":"1.8.0","edition":"Community","flags":[]}}`)
+			case "/api/v1/metrics":
+				fmt.Fprint(w, `{"input":{},"output":{}}`)
+			}
+		}))
+		defer srv.Close()
+
+		client := &Client{
+			HTTPClient: srv.Client(),
+			BaseURL:    srv.URL,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		err := client.WaitReady(ctx, 10*time.Millisecond)
+		if !errors.Is(err, errNoInputs) {
+			t.Fatalf("want error %v; got %v", errNoInputs, err)
+		}
+	})
+	t.Run("zero_interval", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				fmt.Fprint(w, `{"fluent-bit":{"version":"1.8.0","edition":"Community","flags":[]}}`)
+			case "/api/v1/metrics":
+				fmt.Fprint(w, `{"input":{},"output":{}}`)
+			}
+		}))
+		defer srv.Close()
+
+		client := &Client{
+			HTTPClient: srv.Client(),
+			BaseURL:    srv.URL,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		// polls every DefaultHTTPRetryBackoff rather than panicking.
+		err := client.WaitReady(ctx, 0)
+		if !errors.Is(err, errNoInputs) {
+			t.Fatalf("want error %v; got %v", errNoInputs, err)
+		}
+	})
+}
+
+func TestClient_WaitForOutputRecords(t *testing.T) {
+	var polls uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddUint64(&polls, 1)
+		fmt.Fprintf(w, `{"input":{},"output":{"stdout.0":{"proc_records":%d}}}`, n*10)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.WaitForOutputRecords(ctx, "stdout.0", 30, 10*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err := client.WaitForOutputRecords(ctx, "forward.0", 1, 10*time.Millisecond)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want error %v; got %v", context.DeadlineExceeded, err)
+		}
+
+		if want := "output not found"; !strings.Contains(err.Error(), want) {
+			t.Fatalf("want error to contain %q; got %q", want, err)
+		}
+	})
+
+	t.Run("zero_interval", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.WaitForOutputRecords(ctx, "stdout.0", 1, 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TimedMetrics is a Metrics snapshot along with the time it was taken.
+//
+// Its JSON encoding is stable, so snapshots can be persisted
+// and reloaded with DecodeSnapshot to compute rates against live ones
+// across process restarts. Metrics is encoded like the
+// /api/v1/metrics payload, plus the "upstream" connection counts
+// when parsed from Prometheus samples, and the time in RFC 3339 format
+// with nanoseconds.
+type TimedMetrics struct {
+	Time    time.Time `json:"time"`
+	Metrics Metrics   `json:"metrics"`
+}
+
+// DecodeSnapshot decodes a JSON encoded TimedMetrics.
+func DecodeSnapshot(raw []byte) (TimedMetrics, error) {
+	var tm TimedMetrics
+	if err := json.Unmarshal(raw, &tm); err != nil {
+		return TimedMetrics{}, fmt.Errorf("could not decode snapshot: %w", err)
+	}
+
+	return tm, nil
+}
+
+// Recorder keeps a bounded ring buffer of the most recent Metrics snapshots.
+// It is safe for one writer and many concurrent readers.
+type Recorder struct {
+	mu   sync.RWMutex
+	buf  []TimedMetrics
+	next int
+	full bool
+}
+
+// NewRecorder returns a Recorder that keeps the last size snapshots.
+// It panics if size is less than 1.
+func NewRecorder(size int) *Recorder {
+	if size < 1 {
+		panic("fluentbit: recorder size must be at least 1")
+	}
+
+	return &Recorder{buf: make([]TimedMetrics, size)}
+}
+
+// Push records the result of metrics timestamped at the current time.
+// The recorder is left untouched if metrics fails.
+func (r *Recorder) Push(metrics func() (Metrics, error)) error {
+	mm, err := metrics()
+	if err != nil {
+		return err
+	}
+
+	r.Record(mm, time.Now())
+	return nil
+}
+
+// Record adds a snapshot taken at the given time,
+// overwriting the oldest one once the buffer is full.
+func (r *Recorder) Record(mm Metrics, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf[r.next] = TimedMetrics{Time: at, Metrics: mm}
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Snapshots returns the recorded snapshots, oldest first.
+func (r *Recorder) Snapshots() []TimedMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.full {
+		out := make([]TimedMetrics, r.next)
+		copy(out, r.buf[:r.next])
+		return out
+	}
+
+	out := make([]TimedMetrics, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	out = append(out, r.buf[:r.next]...)
+	return out
+}
+
+// Rates returns the rates between each pair of adjacent snapshots, oldest first.
+// It returns nil with less than two snapshots.
+func (r *Recorder) Rates() []MetricsRate {
+	snapshots := r.Snapshots()
+	if len(snapshots) < 2 {
+		return nil
+	}
+
+	out := make([]MetricsRate, 0, len(snapshots)-1)
+	for i := 1; i < len(snapshots); i++ {
+		prev, curr := snapshots[i-1], snapshots[i]
+		out = append(out, Rate(prev.Metrics, curr.Metrics, curr.Time.Sub(prev.Time)))
+	}
+	return out
+}
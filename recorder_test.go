@@ -0,0 +1,97 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(2)
+	if got := r.Rates(); got != nil {
+		t.Fatalf("want nil rates; got %+v", got)
+	}
+
+	start := time.Now()
+	for i := uint64(1); i <= 3; i++ {
+		r.Record(Metrics{
+			Input: map[string]MetricInput{
+				"cpu.0": {Records: i * 10},
+			},
+		}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	snapshots := r.Snapshots()
+	if want, got := 2, len(snapshots); want != got {
+		t.Fatalf("want %d snapshots; got %d", want, got)
+	}
+
+	if want, got := uint64(20), snapshots[0].Metrics.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want oldest records %d; got %d", want, got)
+	}
+
+	if want, got := uint64(30), snapshots[1].Metrics.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want newest records %d; got %d", want, got)
+	}
+
+	rates := r.Rates()
+	if want, got := 1, len(rates); want != got {
+		t.Fatalf("want %d rates; got %d", want, got)
+	}
+
+	if want, got := 10.0, rates[0].Input["cpu.0"].Records; want != got {
+		t.Fatalf("want records rate %v; got %v", want, got)
+	}
+
+	err := r.Push(func() (Metrics, error) {
+		return Metrics{}, errors.New("some error")
+	})
+	if err == nil {
+		t.Fatal("want push error")
+	}
+
+	if want, got := 2, len(r.Snapshots()); want != got {
+		t.Fatalf("want %d snapshots after failed push; got %d", want, got)
+	}
+}
+
+func TestDecodeSnapshot(t *testing.T) {
+	livePrev := TimedMetrics{
+		Time: time.Now(),
+		Metrics: Metrics{
+			Input:  map[string]MetricInput{"cpu.0": {Records: 10, Bytes: 100}},
+			Filter: map[string]MetricFilter{"grep.0": {DropRecords: 1}},
+			Output: map[string]MetricOutput{"stdout.0": {ProcRecords: 9, ProcBytes: 90, DroppedRecords: 1}},
+		},
+	}
+	liveCurr := TimedMetrics{
+		Time: livePrev.Time.Add(1500 * time.Millisecond),
+		Metrics: Metrics{
+			Input:  map[string]MetricInput{"cpu.0": {Records: 25, Bytes: 250}},
+			Filter: map[string]MetricFilter{"grep.0": {DropRecords: 4}},
+			Output: map[string]MetricOutput{"stdout.0": {ProcRecords: 21, ProcBytes: 210, DroppedRecords: 1}},
+		},
+	}
+
+	raw, err := json.Marshal(livePrev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadedPrev, err := DecodeSnapshot(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Rate(livePrev.Metrics, liveCurr.Metrics, liveCurr.Time.Sub(livePrev.Time))
+	got := Rate(loadedPrev.Metrics, liveCurr.Metrics, liveCurr.Time.Sub(loadedPrev.Time))
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want rates %+v; got %+v", want, got)
+	}
+
+	if _, err := DecodeSnapshot([]byte(`{"time":`)); err == nil {
+		t.Fatal("want error decoding truncated snapshot")
+	}
+}
@@ -0,0 +1,46 @@
+package fluentbit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxRedirects matches the net/http default.
+const maxRedirects = 10
+
+// sensitiveHeaders are removed from requests redirected to another host.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// httpClient returns a shallow copy of HTTPClient applying the redirect policy:
+//   - with DisableRedirects, a redirect fails with ErrRedirectDisabled.
+//   - otherwise, a redirect to another host, including another port
+//     of the same host, drops the sensitive headers. net/http only does so
+//     when the domain changes and keeps them across ports.
+//
+// An HTTPClient.CheckRedirect set by the caller still runs afterwards.
+func (c *Client) httpClient() *http.Client {
+	hc := *c.HTTPClient
+	check := hc.CheckRedirect
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if c.DisableRedirects {
+			return fmt.Errorf("%w: to %s", ErrRedirectDisabled, req.URL.Redacted())
+		}
+
+		if req.URL.Host != via[0].URL.Host {
+			for _, h := range sensitiveHeaders {
+				req.Header.Del(h)
+			}
+		}
+
+		if check != nil {
+			return check(req, via)
+		}
+
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &hc
+}
@@ -0,0 +1,81 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_redirects(t *testing.T) {
+	var gotAuth []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		if r.URL.Path == "/same-host" {
+			http.Redirect(w, r, "/api/v1/uptime", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer target.Close()
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusMovedPermanently)
+	}))
+	defer gateway.Close()
+
+	newClient := func(baseURL string) *Client {
+		return &Client{
+			HTTPClient: &http.Client{},
+			BaseURL:    baseURL,
+			RequestBuilder: func(ctx context.Context, url string) (*http.Request, error) {
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("Authorization", "Bearer token")
+				return req, nil
+			},
+		}
+	}
+
+	t.Run("cross_host", func(t *testing.T) {
+		gotAuth = nil
+		if _, err := newClient(gateway.URL).UpTime(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(gotAuth) != 1 || gotAuth[0] != "" {
+			t.Fatalf("want authorization dropped across hosts; got %q", gotAuth)
+		}
+	})
+
+	t.Run("same_host", func(t *testing.T) {
+		gotAuth = nil
+		var up UpTime
+		if err := newClient(target.URL).Do(context.Background(), "/same-host", &up); err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "Bearer token"; len(gotAuth) != 2 || gotAuth[1] != want {
+			t.Fatalf("want authorization %q kept on the same host; got %q", want, gotAuth)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		gotAuth = nil
+		client := newClient(gateway.URL)
+		client.DisableRedirects = true
+
+		_, err := client.UpTime(context.Background())
+		if !errors.Is(err, ErrRedirectDisabled) {
+			t.Fatalf("want error %v; got %v", ErrRedirectDisabled, err)
+		}
+
+		if len(gotAuth) != 0 {
+			t.Fatalf("want no request to the redirect target; got %d", len(gotAuth))
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package fluentbit
+
+import "context"
+
+// HotReload payload returned by GET /api/v2/reload
+//
+// Fluent Bit doesn't expose a hash or digest of the running config,
+// so the reload count is the closest proxy to detect config drift:
+// a count that changed since the config was deployed means
+// the running config was reloaded from whatever is on disk.
+// Pair it with ClassifyReset to tell reload resets from anomalous drops.
+type HotReload struct {
+	HotReloadCount uint64 `json:"hot_reload_count"`
+}
+
+// HotReload returns the hot reload status.
+// It requires Fluent Bit v2.1 or later with Hot_Reload On,
+// otherwise it fails with ErrEndpointNotFound.
+//
+// Reloading is the only control the monitoring API offers: no Fluent Bit
+// version has endpoints to pause or resume individual inputs, so there is
+// no such method here. Inputs pause on their own over their
+// mem_buf_limit, see StorageMetrics.PausedInputs.
+func (c *Client) HotReload(ctx context.Context) (HotReload, error) {
+	var hr HotReload
+	return hr, c.fetchJSON(ctx, "/api/v2/reload", &hr)
+}
@@ -0,0 +1,40 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_HotReload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/reload" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"hot_reload_count":2}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	hr, err := client.HotReload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(2), hr.HotReloadCount; want != got {
+		t.Fatalf("want hot reload count %d; got %d", want, got)
+	}
+
+	client.BaseURL = srv.URL + "/v1.9"
+	if _, err := client.HotReload(context.Background()); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("want error %v; got %v", ErrEndpointNotFound, err)
+	}
+}
@@ -0,0 +1,147 @@
+package fluentbit
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+// RemoteWriter pushes metrics to a Prometheus remote-write endpoint.
+//
+// Each plugin counter becomes a series named by Namer, by default
+// PrometheusNamer which names it like Fluent Bit does on its
+// Prometheus endpoint, e.g. fluentbit_output_proc_records_total,
+// labeled with the plugin instance name as "name"
+// plus the static Labels, e.g. a tenant or cluster label;
+// see ValidateLabels for the valid names.
+// Values are the raw cumulative counters, so Prometheus' rate()
+// handles Fluent Bit restarts as regular counter resets.
+type RemoteWriter struct {
+	URL        string
+	HTTPClient *http.Client
+	// Header is added to each push request, e.g. for authentication.
+	Header http.Header
+	Labels map[string]string
+	Namer  MetricNamer
+}
+
+// NewRemoteWriter returns a RemoteWriter pushing to url using http.DefaultClient.
+func NewRemoteWriter(url string) *RemoteWriter {
+	return &RemoteWriter{
+		URL:        url,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Push sends m as a snappy compressed remote-write request
+// with samples timestamped at the current time.
+func (rw *RemoteWriter) Push(ctx context.Context, m Metrics) error {
+	return rw.PushAt(ctx, m, time.Now())
+}
+
+// PushAt is like Push but with samples timestamped at ts.
+func (rw *RemoteWriter) PushAt(ctx context.Context, m Metrics, ts time.Time) error {
+	if err := ValidateLabels(rw.Labels); err != nil {
+		return err
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(m, ts, rw.Labels, namerOrDefault(rw.Namer, PrometheusNamer)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rw.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create remote-write request: %w", err)
+	}
+
+	for k, vv := range rw.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := rw.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not push metrics: %w", err)
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("remote-write failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// encodeWriteRequest encodes m as a prometheus.WriteRequest protobuf message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(m Metrics, ts time.Time, static map[string]string, namer MetricNamer) []byte {
+	millis := ts.UnixNano() / int64(time.Millisecond)
+
+	var req, series, buf []byte
+	for _, c := range m.counters() {
+		series = series[:0]
+		labels := append([][2]string{
+			{"__name__", namer.name(c)},
+			{"name", c.plugin},
+		}, sortedLabels(static)...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+		for _, l := range labels {
+			buf = buf[:0]
+			buf = appendProtoString(buf, 1, l[0])
+			buf = appendProtoString(buf, 2, l[1])
+			series = appendProtoBytes(series, 1, buf)
+		}
+
+		buf = buf[:0]
+		buf = appendProtoTag(buf, 1, 1)
+		buf = appendFixed64(buf, math.Float64bits(float64(c.value)))
+		buf = appendProtoTag(buf, 2, 0)
+		buf = appendUvarint(buf, uint64(millis))
+		series = appendProtoBytes(series, 2, buf)
+
+		req = appendProtoBytes(req, 1, series)
+	}
+	return req
+}
+
+func appendProtoTag(b []byte, field, wireType int) []byte {
+	return appendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	b = appendProtoTag(b, field, 2)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendProtoString(b []byte, field int, v string) []byte {
+	return appendProtoBytes(b, field, []byte(v))
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(b, tmp[:n]...)
+}
@@ -0,0 +1,176 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+type testSample struct {
+	labels map[string]string
+	value  float64
+	ts     int64
+}
+
+// decodeWriteRequest is a minimal protobuf decoder for encodeWriteRequest output.
+func decodeWriteRequest(t *testing.T, b []byte) []testSample {
+	t.Helper()
+
+	fields := func(b []byte, fn func(field int, v []byte, n uint64)) {
+		for len(b) > 0 {
+			tag, n := binary.Uvarint(b)
+			b = b[n:]
+			switch tag & 7 {
+			case 0:
+				v, n := binary.Uvarint(b)
+				b = b[n:]
+				fn(int(tag>>3), nil, v)
+			case 1:
+				fn(int(tag>>3), b[:8], 0)
+				b = b[8:]
+			case 2:
+				l, n := binary.Uvarint(b)
+				b = b[n:]
+				fn(int(tag>>3), b[:l], 0)
+				b = b[l:]
+			default:
+				t.Fatalf("unexpected wire type %d", tag&7)
+			}
+		}
+	}
+
+	var out []testSample
+	fields(b, func(_ int, series []byte, _ uint64) {
+		s := testSample{labels: map[string]string{}}
+		fields(series, func(field int, v []byte, _ uint64) {
+			switch field {
+			case 1:
+				var name, value string
+				fields(v, func(field int, v []byte, _ uint64) {
+					if field == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				s.labels[name] = value
+			case 2:
+				fields(v, func(field int, v []byte, n uint64) {
+					if field == 1 {
+						s.value = math.Float64frombits(binary.LittleEndian.Uint64(v))
+					} else {
+						s.ts = int64(n)
+					}
+				})
+			}
+		})
+		out = append(out, s)
+	})
+	return out
+}
+
+func TestRemoteWriter_Push(t *testing.T) {
+	var got []testSample
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		b, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got = decodeWriteRequest(t, b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	rw := NewRemoteWriter(srv.URL)
+	rw.Header = http.Header{"Authorization": []string{"Bearer token"}}
+
+	ts := time.Unix(1630454400, 0)
+	err := rw.PushAt(context.Background(), Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 3, Bytes: 30},
+		},
+	}, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []testSample{
+		{
+			labels: map[string]string{"__name__": "fluentbit_input_records_total", "name": "cpu.0"},
+			value:  3,
+			ts:     1630454400000,
+		},
+		{
+			labels: map[string]string{"__name__": "fluentbit_input_bytes_total", "name": "cpu.0"},
+			value:  30,
+			ts:     1630454400000,
+		},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want samples %+v; got %+v", want, got)
+	}
+
+	rw.Header = nil
+	if err := rw.Push(context.Background(), Metrics{}); err == nil {
+		t.Fatal("want error on rejected push")
+	}
+}
+
+func Test_encodeWriteRequest_labels(t *testing.T) {
+	b := encodeWriteRequest(Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 3},
+		},
+	}, time.Unix(1630454400, 0), map[string]string{"tenant": "acme"}, PrometheusNamer)
+
+	got := decodeWriteRequest(t, b)
+	if len(got) == 0 {
+		t.Fatal("want samples")
+	}
+
+	want := map[string]string{"__name__": "fluentbit_input_records_total", "name": "cpu.0", "tenant": "acme"}
+	if !reflect.DeepEqual(want, got[0].labels) {
+		t.Fatalf("want labels %+v; got %+v", want, got[0].labels)
+	}
+}
+
+func Test_encodeWriteRequest_namer(t *testing.T) {
+	namer := func(section, plugin, field string) string {
+		return "acme:fluentbit:" + section + ":" + field
+	}
+	b := encodeWriteRequest(Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 3},
+		},
+	}, time.Unix(1630454400, 0), nil, namer)
+
+	got := decodeWriteRequest(t, b)
+	if len(got) == 0 {
+		t.Fatal("want samples")
+	}
+
+	if want, got := "acme:fluentbit:input:records", got[0].labels["__name__"]; want != got {
+		t.Fatalf("want name %q; got %q", want, got)
+	}
+}
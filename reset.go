@@ -0,0 +1,87 @@
+package fluentbit
+
+// CounterReset is a counter whose value went down between two snapshots.
+type CounterReset struct {
+	Section string // input, filter or output
+	Plugin  string // plugin instance name
+	Field   string // JSON field name, e.g. proc_records
+	Prev    uint64
+	Curr    uint64
+}
+
+// ResetReport classifies the counters shared by two Metrics snapshots.
+type ResetReport struct {
+	// Expected holds the counters that reset because of a hot reload.
+	Expected []CounterReset
+	// Anomalous holds the counters that went down with no reload in between,
+	// e.g. because Fluent Bit restarted or a counter misbehaved.
+	Anomalous []CounterReset
+	// Continued is the number of counters that kept counting.
+	Continued int
+}
+
+// HasAnomalies reports whether any counter went down unexpectedly.
+func (r ResetReport) HasAnomalies() bool {
+	return len(r.Anomalous) != 0
+}
+
+// ClassifyReset compares the counters of two snapshots.
+// Set reloaded when the hot reload count changed between prev and curr;
+// a reload restarts every plugin so their counters start from zero again.
+// Counters of plugins present in only one of the snapshots are ignored.
+func ClassifyReset(prev, curr Metrics, reloaded bool) ResetReport {
+	prevCounters := map[counterKey]uint64{}
+	for _, c := range prev.counters() {
+		prevCounters[counterKey{c.section, c.plugin, c.field}] = c.value
+	}
+
+	var r ResetReport
+	for _, c := range curr.counters() {
+		p, ok := prevCounters[counterKey{c.section, c.plugin, c.field}]
+		if !ok {
+			continue
+		}
+
+		if c.value >= p {
+			r.Continued++
+			continue
+		}
+
+		reset := CounterReset{
+			Section: c.section,
+			Plugin:  c.plugin,
+			Field:   c.field,
+			Prev:    p,
+			Curr:    c.value,
+		}
+		if reloaded {
+			r.Expected = append(r.Expected, reset)
+		} else {
+			r.Anomalous = append(r.Anomalous, reset)
+		}
+	}
+	return r
+}
+
+type counterKey struct {
+	section, plugin, field string
+}
+
+// AnomalousDecreases returns the counters that went down between prev
+// and curr although Fluent Bit didn't restart, named like
+// "output.stdout.0.proc_records" in section, plugin and field order.
+//
+// Counters are monotonic for the lifetime of a process, so once restarts
+// are ruled out a decrease points at a data integrity problem, usually a
+// cache or proxy serving stale data or a load balancer alternating between
+// instances. Set restarted when the uptime went down or the hot reload
+// count changed between the snapshots; then every decrease is expected
+// and it returns nil. The heuristic assumes prev and curr come from the
+// same BaseURL and that plugin names are stable across both.
+func AnomalousDecreases(prev, curr Metrics, restarted bool) []string {
+	var out []string
+	for _, r := range ClassifyReset(prev, curr, restarted).Anomalous {
+		out = append(out, r.Section+"."+r.Plugin+"."+r.Field)
+	}
+	return out
+}
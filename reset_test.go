@@ -0,0 +1,87 @@
+package fluentbit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyReset(t *testing.T) {
+	prev := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 10, Bytes: 100},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 10, ProcBytes: 100},
+			"gone.0":   {ProcRecords: 5},
+		},
+	}
+	curr := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 2, Bytes: 150},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 12, ProcBytes: 20},
+			"new.0":    {ProcRecords: 1},
+		},
+	}
+	resets := []CounterReset{
+		{Section: "input", Plugin: "cpu.0", Field: "records", Prev: 10, Curr: 2},
+		{Section: "output", Plugin: "stdout.0", Field: "proc_bytes", Prev: 100, Curr: 20},
+	}
+
+	t.Run("reloaded", func(t *testing.T) {
+		got := ClassifyReset(prev, curr, true)
+		if !reflect.DeepEqual(resets, got.Expected) {
+			t.Errorf("want expected resets %+v; got %+v", resets, got.Expected)
+		}
+		if got.HasAnomalies() {
+			t.Errorf("want no anomalies; got %+v", got.Anomalous)
+		}
+		// input bytes plus every stdout.0 counter but proc_bytes.
+		if want := 7; got.Continued != want {
+			t.Errorf("want %d continued counters; got %d", want, got.Continued)
+		}
+	})
+
+	t.Run("not_reloaded", func(t *testing.T) {
+		got := ClassifyReset(prev, curr, false)
+		if got.Expected != nil {
+			t.Errorf("want no expected resets; got %+v", got.Expected)
+		}
+		if !reflect.DeepEqual(resets, got.Anomalous) {
+			t.Errorf("want anomalous resets %+v; got %+v", resets, got.Anomalous)
+		}
+	})
+}
+
+func TestAnomalousDecreases(t *testing.T) {
+	prev := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 10, Bytes: 100},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 10, ProcBytes: 100},
+		},
+	}
+	curr := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 12, Bytes: 120},
+		},
+		Output: map[string]MetricOutput{
+			"stdout.0": {ProcRecords: 8, ProcBytes: 80},
+		},
+	}
+
+	want := []string{"output.stdout.0.proc_records", "output.stdout.0.proc_bytes"}
+	if got := AnomalousDecreases(prev, curr, false); !reflect.DeepEqual(want, got) {
+		t.Fatalf("want anomalous decreases %v; got %v", want, got)
+	}
+
+	if got := AnomalousDecreases(prev, curr, true); got != nil {
+		t.Fatalf("want no anomalous decreases after a restart; got %v", got)
+	}
+
+	if got := AnomalousDecreases(prev, prev, false); got != nil {
+		t.Fatalf("want no anomalous decreases; got %v", got)
+	}
+}
@@ -0,0 +1,71 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// retrier is the attempt and backoff loop shared by Client and
+// RetryTransport so that both retry the same way.
+type retrier struct {
+	// target names what is being reached in timeout errors.
+	target  string
+	backoff time.Duration
+	// onRetry, if set, is called with each attempt that is retried.
+	onRetry func(attempt int, err error)
+}
+
+// attemptFunc sends attempt number n, starting at 1. It reports done
+// once its outcome, success or err, is final. Otherwise err is why it
+// is retried, after at least wait.
+type attemptFunc func(ctx context.Context, n int) (done bool, wait time.Duration, err error)
+
+// run calls attempt until it's done or ctx is. Each attempt, the first
+// one included, starts one backoff, or the wait asked for if longer,
+// after the previous one.
+// Once ctx is done it returns a timeout error, which matches
+// ErrServerUnreachable if the last attempt not cut short by ctx
+// couldn't connect.
+func (r retrier) run(ctx context.Context, attempt attemptFunc) error {
+	// lastErr is kept apart since the deadline can land right on an
+	// attempt, which then fails with the error of ctx.
+	var lastErr error
+	wait := r.backoff
+	for n := 1; ; n++ {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return r.timeoutError(lastErr)
+		case <-timer.C:
+		}
+
+		done, after, err := attempt(ctx, n)
+		if done {
+			return err
+		}
+
+		if ctx.Err() == nil {
+			lastErr = err
+		}
+		if r.onRetry != nil {
+			r.onRetry(n, err)
+		}
+
+		wait = r.backoff
+		if after > wait {
+			wait = after
+		}
+	}
+}
+
+func (r retrier) timeoutError(lastErr error) error {
+	if isDialError(lastErr) {
+		return &EndpointError{Endpoint: r.target, Err: unreachableError(lastErr)}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("timeout while trying to reach: %s: %w", r.target, lastErr)
+	}
+	return fmt.Errorf("timeout while trying to reach: %s", r.target)
+}
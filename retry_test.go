@@ -0,0 +1,79 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetrier(t *testing.T) {
+	t.Run("wait", func(t *testing.T) {
+		var starts []time.Time
+		r := retrier{target: "/api/v1/uptime", backoff: time.Millisecond}
+		err := r.run(context.Background(), func(_ context.Context, n int) (bool, time.Duration, error) {
+			starts = append(starts, time.Now())
+			if n == 1 {
+				return false, 50 * time.Millisecond, errors.New("failed with status code 429")
+			}
+			return true, 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want, got := 2, len(starts); want != got {
+			t.Fatalf("want %d attempts; got %d", want, got)
+		}
+		if d := starts[1].Sub(starts[0]); d < 50*time.Millisecond {
+			t.Fatalf("want retry after the asked wait; got %s", d)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		var retried int
+		r := retrier{
+			target:  "/api/v1/uptime",
+			backoff: time.Millisecond,
+			onRetry: func(int, error) { retried++ },
+		}
+		err := r.run(ctx, func(context.Context, int) (bool, time.Duration, error) {
+			return false, 0, errors.New("failed with status code 404")
+		})
+
+		if want, got := "timeout while trying to reach: /api/v1/uptime: failed with status code 404", err.Error(); want != got {
+			t.Fatalf("want error %q; got %q", want, got)
+		}
+		if retried == 0 {
+			t.Fatal("want retries observed")
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		r := retrier{target: "/api/v1/uptime", backoff: time.Millisecond}
+		err := r.run(ctx, func(ctx context.Context, _ int) (bool, time.Duration, error) {
+			// a deadline landing on an attempt doesn't hide why the
+			// previous ones failed.
+			if deadline, _ := ctx.Deadline(); time.Until(deadline) < 5*time.Millisecond {
+				<-ctx.Done()
+				return false, 0, ctx.Err()
+			}
+			return false, 0, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		})
+
+		if !errors.Is(err, ErrServerUnreachable) {
+			t.Fatalf("want error %v; got %v", ErrServerUnreachable, err)
+		}
+		if !strings.HasPrefix(err.Error(), "/api/v1/uptime: ") {
+			t.Fatalf("want error naming the target; got %q", err)
+		}
+	})
+}
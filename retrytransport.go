@@ -0,0 +1,144 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// RetryOptions tunes the transport built by NewRetryTransport.
+// Zero values take the package defaults.
+type RetryOptions struct {
+	// Transport sends each attempt. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Backoff is the wait between attempts.
+	// Defaults to DefaultHTTPRetryBackoff.
+	Backoff time.Duration
+	// Timeout bounds all the attempts of requests whose context
+	// has no deadline. Defaults to DefaultHTTPRetryTimeout.
+	Timeout time.Duration
+	// AttemptTimeout, if positive, bounds each individual attempt.
+	AttemptTimeout time.Duration
+	// RetryNotFound makes 404 responses be retried.
+	RetryNotFound bool
+	// HonorRetryAfter makes 429 responses be retried
+	// after the duration given by their Retry-After header.
+	HonorRetryAfter bool
+}
+
+// RetryTransport is an http.RoundTripper retrying requests the way
+// Client does, for calls to endpoints the typed methods don't cover:
+// attempts start every Backoff, the first one included, and connection
+// errors are retried until the request context is done or Timeout
+// elapses, and so are 404 and 429 responses if enabled.
+// Other responses, including 5xx ones, are returned as is.
+// Once out of time, connection errors match ErrServerUnreachable.
+//
+// Requests with a body are only retried if they have GetBody set,
+// as http.NewRequest does for common body types.
+type RetryTransport struct {
+	opts RetryOptions
+}
+
+// NewRetryTransport returns a RetryTransport to plug into any http.Client:
+//
+//	hc := &http.Client{Transport: fluentbit.NewRetryTransport(fluentbit.RetryOptions{})}
+func NewRetryTransport(opts RetryOptions) *RetryTransport {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultHTTPRetryBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHTTPRetryTimeout
+	}
+
+	return &RetryTransport{opts: opts}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok {
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+	}
+
+	canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	var resp *http.Response
+	retry := retrier{target: req.URL.Redacted(), backoff: t.opts.Backoff}
+	err := retry.run(ctx, func(ctx context.Context, attempt int) (bool, time.Duration, error) {
+		r := req.Clone(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return true, 0, fmt.Errorf("could not rewind request body: %w", err)
+			}
+			r.Body = body
+		}
+
+		var wait time.Duration
+		var err error
+		resp, wait, err = t.attempt(r, canRetry, cancel)
+		return err == nil || !canRetry, wait, err
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// attempt sends req once. It returns either the response to hand back
+// to the caller, whose body calls cancel once closed,
+// or the error of an attempt to retry after wait.
+// Without retry, every response is handed back.
+func (t *RetryTransport) attempt(req *http.Request, retry bool, cancel context.CancelFunc) (*http.Response, time.Duration, error) {
+	cancelAttempt := context.CancelFunc(func() {})
+	if t.opts.AttemptTimeout > 0 {
+		var ctx context.Context
+		ctx, cancelAttempt = context.WithTimeout(req.Context(), t.opts.AttemptTimeout)
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := t.opts.Transport.RoundTrip(req)
+	if err != nil {
+		cancelAttempt()
+		return nil, 0, err
+	}
+
+	var wait time.Duration
+	switch {
+	case retry && resp.StatusCode == http.StatusNotFound && t.opts.RetryNotFound:
+	case retry && resp.StatusCode == http.StatusTooManyRequests && t.opts.HonorRetryAfter:
+		wait, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	default:
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() {
+			cancelAttempt()
+			cancel()
+		}}
+		return resp, 0, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	cancelAttempt()
+	return nil, wait, fmt.Errorf("failed with status code %d", resp.StatusCode)
+}
+
+// cancelOnClose releases a context once the response body is closed,
+// since canceling it earlier would abort reading the body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
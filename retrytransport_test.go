@@ -0,0 +1,196 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newTestResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Run("connection_error", func(t *testing.T) {
+		var attempts int
+		rt := NewRetryTransport(RetryOptions{
+			Backoff: time.Millisecond,
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if attempts < 3 {
+					return nil, errors.New("connection refused")
+				}
+				return newTestResponse(http.StatusOK, "ok"), nil
+			}),
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "http://fluentbit/api/v1/uptime", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want, got := "ok", string(body); want != got {
+			t.Fatalf("want body %q; got %q", want, got)
+		}
+		if want, got := 3, attempts; want != got {
+			t.Fatalf("want %d attempts; got %d", want, got)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		statuses := []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError}
+		var attempts int
+		rt := NewRetryTransport(RetryOptions{
+			Backoff:         time.Millisecond,
+			RetryNotFound:   true,
+			HonorRetryAfter: true,
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				resp := newTestResponse(statuses[attempts], "")
+				resp.Header.Set("Retry-After", "0")
+				attempts++
+				return resp, nil
+			}),
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "http://fluentbit/api/v1/uptime", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// 5xx responses are handed back.
+		if want, got := http.StatusInternalServerError, resp.StatusCode; want != got {
+			t.Fatalf("want status %d; got %d", want, got)
+		}
+		if want, got := 3, attempts; want != got {
+			t.Fatalf("want %d attempts; got %d", want, got)
+		}
+	})
+
+	t.Run("body", func(t *testing.T) {
+		var bodies []string
+		rt := NewRetryTransport(RetryOptions{
+			Backoff: time.Millisecond,
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, err := io.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				bodies = append(bodies, string(b))
+				if len(bodies) == 1 {
+					return nil, errors.New("connection reset")
+				}
+				return newTestResponse(http.StatusOK, ""), nil
+			}),
+		})
+
+		req, err := http.NewRequest(http.MethodPost, "http://fluentbit/api/v2/reload", strings.NewReader("reload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if len(bodies) != 2 || bodies[1] != "reload" {
+			t.Fatalf("want body resent; got %q", bodies)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		rt := NewRetryTransport(RetryOptions{
+			Backoff: time.Millisecond,
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			}),
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://fluentbit/api/v1/uptime", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = rt.RoundTrip(req)
+		if err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Fatalf("want timeout error with the last error; got %v", err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		rt := NewRetryTransport(RetryOptions{Timeout: 4 * DefaultHTTPRetryBackoff})
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/uptime", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// same error as Client gives for a server it can't reach.
+		_, err = rt.RoundTrip(req)
+		if !errors.Is(err, ErrServerUnreachable) {
+			t.Fatalf("want error %v; got %v", ErrServerUnreachable, err)
+		}
+	})
+}
+
+func TestRetryTransport_httpClient(t *testing.T) {
+	var attempts int
+	hc := &http.Client{Transport: NewRetryTransport(RetryOptions{
+		Backoff: time.Millisecond,
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errors.New("connection refused")
+			}
+			return newTestResponse(http.StatusOK, `{"uptime_sec":1}`), nil
+		}),
+	})}
+
+	client := &Client{HTTPClient: hc, BaseURL: "http://fluentbit"}
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("want uptime %d; got %d", want, got)
+	}
+}
@@ -0,0 +1,273 @@
+package fluentbit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Schema of a metrics payload.
+type Schema int
+
+const (
+	SchemaUnknown Schema = iota
+	// SchemaV1 is the JSON returned by GET /api/v1/metrics
+	SchemaV1
+	// SchemaV2 is the cmetrics JSON returned by GET /api/v2/metrics
+	SchemaV2
+	// SchemaPrometheus is the text exposition format returned by
+	// GET /api/v1/metrics/prometheus and GET /api/v2/metrics/prometheus
+	SchemaPrometheus
+)
+
+func (s Schema) String() string {
+	switch s {
+	case SchemaV1:
+		return "v1"
+	case SchemaV2:
+		return "v2"
+	case SchemaPrometheus:
+		return "prometheus"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectSchema guesses the schema of a metrics payload
+// from its characteristic keys:
+//   - a JSON object with a "metrics" array is SchemaV2.
+//   - a JSON object with "input", "filter" or "output" keys is SchemaV1.
+//   - anything starting with a "#" comment or a metric name is SchemaPrometheus.
+//
+// Detection only looks at the top level shape, so a payload
+// may still fail to decode with the detected schema.
+func DetectSchema(raw []byte) Schema {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return SchemaUnknown
+	}
+
+	if raw[0] == '{' {
+		var top map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &top); err != nil {
+			return SchemaUnknown
+		}
+
+		if m, ok := top["metrics"]; ok && bytes.HasPrefix(bytes.TrimSpace(m), []byte("[")) {
+			return SchemaV2
+		}
+
+		for _, key := range []string{"input", "filter", "output"} {
+			if _, ok := top[key]; ok {
+				return SchemaV1
+			}
+		}
+
+		return SchemaUnknown
+	}
+
+	if raw[0] == '#' || isPromNameStart(raw[0]) {
+		return SchemaPrometheus
+	}
+
+	return SchemaUnknown
+}
+
+func isPromNameStart(b byte) bool {
+	return b == '_' || b == ':' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// DecodeMetrics decodes a metrics payload of any known schema into Metrics.
+func DecodeMetrics(raw []byte) (Metrics, error) {
+	switch schema := DetectSchema(raw); schema {
+	case SchemaV1:
+		var mm Metrics
+		if err := json.Unmarshal(raw, &mm); err != nil {
+			return Metrics{}, fmt.Errorf("could not decode %s metrics: %w", schema, err)
+		}
+		return mm, nil
+	case SchemaV2:
+		samples, err := parseCMetricsJSON(raw)
+		if err != nil {
+			return Metrics{}, fmt.Errorf("could not decode %s metrics: %w", schema, err)
+		}
+		return MetricsFromPrometheus(samples), nil
+	case SchemaPrometheus:
+		samples, err := ParsePrometheus(bytes.NewReader(raw))
+		if err != nil {
+			return Metrics{}, fmt.Errorf("could not decode %s metrics: %w", schema, err)
+		}
+		return MetricsFromPrometheus(samples), nil
+	default:
+		return Metrics{}, errors.New("could not detect metrics schema")
+	}
+}
+
+// cmetricsJSON is the JSON representation of cmetrics contexts.
+type cmetricsJSON struct {
+	Metrics []struct {
+		Meta struct {
+			Type int `json:"type"`
+			Opts struct {
+				Namespace string `json:"ns"`
+				Subsystem string `json:"ss"`
+				Name      string `json:"name"`
+			} `json:"opts"`
+			Labels []string `json:"labels"`
+		} `json:"meta"`
+		Values []struct {
+			Value  float64  `json:"value"`
+			Labels []string `json:"labels"`
+		} `json:"values"`
+	} `json:"metrics"`
+}
+
+// cmetrics metric types.
+var cmetricsTypes = map[int]string{
+	0: "counter",
+	1: "gauge",
+	2: "histogram",
+	3: "summary",
+	4: "untyped",
+}
+
+// parseCMetricsJSON flattens cmetrics JSON into Prometheus samples,
+// naming them "namespace_subsystem_name" as cmetrics does.
+func parseCMetricsJSON(raw []byte) ([]PromMetric, error) {
+	var v cmetricsJSON
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	var out []PromMetric
+	for _, m := range v.Metrics {
+		var parts []string
+		for _, p := range []string{m.Meta.Opts.Namespace, m.Meta.Opts.Subsystem, m.Meta.Opts.Name} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		name := strings.Join(parts, "_")
+
+		for _, val := range m.Values {
+			var labels map[string]string
+			if len(m.Meta.Labels) != 0 {
+				labels = make(map[string]string, len(m.Meta.Labels))
+				for i, key := range m.Meta.Labels {
+					if i < len(val.Labels) {
+						labels[key] = val.Labels[i]
+					}
+				}
+			}
+
+			out = append(out, PromMetric{
+				Name:   name,
+				Labels: labels,
+				Value:  val.Value,
+				Type:   cmetricsTypes[m.Meta.Type],
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// UnmarshalJSON decodes the /api/v1/metrics payload. Besides the usual
+// objects keyed by plugin instance name, the input, filter and output
+// sections may be arrays of objects carrying the instance "name",
+// as some builds and proxies emit them, e.g.
+// {"input":[{"name":"cpu.0","records":1,"bytes":10}]}.
+// Both shapes decode into the same maps in a single pass.
+func (m *Metrics) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	return decodeObject(dec, func(key string) error {
+		var err error
+		switch key {
+		case "input":
+			err = decodePlugins(dec, func() counters { return &MetricInput{} }, func(name string, c counters) {
+				if m.Input == nil {
+					m.Input = make(map[string]MetricInput)
+				}
+				m.Input[name] = *c.(*MetricInput)
+			})
+		case "filter":
+			err = decodePlugins(dec, func() counters { return &MetricFilter{} }, func(name string, c counters) {
+				if m.Filter == nil {
+					m.Filter = make(map[string]MetricFilter)
+				}
+				m.Filter[name] = *c.(*MetricFilter)
+			})
+		case "output":
+			err = decodePlugins(dec, func() counters { return &MetricOutput{} }, func(name string, c counters) {
+				if m.Output == nil {
+					m.Output = make(map[string]MetricOutput)
+				}
+				m.Output[name] = *c.(*MetricOutput)
+			})
+		case "upstream":
+			return dec.Decode(&m.Upstream)
+		default:
+			return skipValue(dec)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// decodePlugins decodes a plugins section from dec, telling the object
+// and array shapes apart by their first token, and hands each instance
+// created by newPlugin to add along with its name.
+func decodePlugins(dec *json.Decoder, newPlugin func() counters, add func(name string, c counters)) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case nil:
+		return nil
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			c := newPlugin()
+			if err := dec.Decode(c); err != nil {
+				return err
+			}
+			add(tok.(string), c)
+		}
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			var name string
+			c := newPlugin()
+			err := decodeCounters(dec, c, func(key string) error {
+				if key != "name" {
+					return skipValue(dec)
+				}
+				return dec.Decode(&name)
+			})
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				return fmt.Errorf("plugin %d has no name", i)
+			}
+			add(name, c)
+		}
+	default:
+		return fmt.Errorf("expected object or array, got %v", tok)
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// metricsJSON is Metrics without its UnmarshalJSON method, handed to
+// Client.Decoder so that it decodes the payload by itself.
+type metricsJSON Metrics
@@ -0,0 +1,112 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDetectSchema(t *testing.T) {
+	tt := []struct {
+		file string
+		want Schema
+	}{
+		{"testdata/metrics_v1_8.json", SchemaV1},
+		{"testdata/metrics_v1_9.json", SchemaV1},
+		{"testdata/metrics_v1_9_array.json", SchemaV1},
+		{"testdata/metrics_v2.json", SchemaV2},
+		{"testdata/prometheus_v2.txt", SchemaPrometheus},
+	}
+	for _, tc := range tt {
+		t.Run(tc.file, func(t *testing.T) {
+			raw, err := os.ReadFile(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := DetectSchema(raw); tc.want != got {
+				t.Fatalf("want schema %s; got %s", tc.want, got)
+			}
+		})
+	}
+
+	for _, raw := range []string{"", "   ", "[]", `{"status":"ok"}`, "{", "123"} {
+		if got := DetectSchema([]byte(raw)); got != SchemaUnknown {
+			t.Errorf("want unknown schema for %q; got %s", raw, got)
+		}
+	}
+}
+
+func TestDecodeMetrics(t *testing.T) {
+	for _, file := range []string{
+		"testdata/metrics_v1_8.json",
+		"testdata/metrics_v1_9.json",
+		"testdata/metrics_v2.json",
+		"testdata/prometheus_v2.txt",
+	} {
+		t.Run(file, func(t *testing.T) {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mm, err := DecodeMetrics(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want, got := (MetricInput{Records: 40, Bytes: 10240}), mm.Input["cpu.0"]; want != got {
+				t.Fatalf("want input %+v; got %+v", want, got)
+			}
+
+			out := mm.Output["stdout.0"]
+			if out.ProcRecords != 38 || out.ProcBytes != 9728 || out.Errors != 1 || out.Retries != 2 {
+				t.Fatalf("unexpected output %+v", out)
+			}
+		})
+	}
+
+	if _, err := DecodeMetrics([]byte(`{"status":"ok"}`)); err == nil {
+		t.Fatal("want error decoding unknown schema")
+	}
+}
+
+func TestMetrics_UnmarshalJSON_arrays(t *testing.T) {
+	decode := func(path string) Metrics {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mm Metrics
+		if err := json.Unmarshal(raw, &mm); err != nil {
+			t.Fatal(err)
+		}
+		return mm
+	}
+
+	want := decode("testdata/metrics_v1_9.json")
+	got := decode("testdata/metrics_v1_9_array.json")
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want metrics %+v; got %+v", want, got)
+	}
+
+	var mm Metrics
+	if err := json.Unmarshal([]byte(`{"input":[{"records":1}]}`), &mm); err == nil {
+		t.Fatal("want error decoding unnamed plugin")
+	}
+
+	mm = Metrics{}
+	err := json.Unmarshal([]byte(`{"uptime":1,"input":[{"name":"cpu.0","type":"cpu","records":1.0}],"filter":null}`), &mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (MetricInput{Records: 1}), mm.Input["cpu.0"]; want != got {
+		t.Fatalf("want input %+v; got %+v", want, got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"output":"stdout.0"}`), &mm); err == nil {
+		t.Fatal("want error decoding section of the wrong shape")
+	}
+}
@@ -0,0 +1,39 @@
+package fluentbit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type scrapeIDKey struct{}
+
+// WithScrapeID returns a copy of ctx carrying the given scrape ID.
+// The client includes it in its log lines and retry observer callbacks
+// so all of them can be correlated to a single scrape.
+func WithScrapeID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, scrapeIDKey{}, id)
+}
+
+// ScrapeIDFromContext returns the scrape ID carried by ctx, if any.
+func ScrapeIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(scrapeIDKey{}).(string)
+	return id, ok
+}
+
+// ensureScrapeID returns ctx along with its scrape ID,
+// generating a new one if ctx doesn't carry any.
+func ensureScrapeID(ctx context.Context) (context.Context, string) {
+	if id, ok := ScrapeIDFromContext(ctx); ok {
+		return ctx, id
+	}
+
+	id := newScrapeID()
+	return WithScrapeID(ctx, id), id
+}
+
+func newScrapeID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,65 @@
+package fluentbit
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_Logger_scrapeID(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"uptime_sec":1}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	var observed []string
+	client := &Client{
+		HTTPClient:    srv.Client(),
+		BaseURL:       srv.URL,
+		RetryNotFound: true,
+		Logger:        log.New(&buf, "", 0),
+		RetryObserver: func(ctx context.Context, endpoint string, attempt int, err error) {
+			id, _ := ScrapeIDFromContext(ctx)
+			observed = append(observed, id)
+		},
+	}
+
+	if _, err := client.UpTime(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(observed); want != got {
+		t.Fatalf("want %d observed retries; got %d", want, got)
+	}
+
+	id := observed[0]
+	if id == "" {
+		t.Fatal("want generated scrape id")
+	}
+
+	if want, got := "scrape_id="+id, buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("want log to contain %q; got %q", want, got)
+	}
+}
+
+func TestScrapeIDFromContext(t *testing.T) {
+	if _, ok := ScrapeIDFromContext(context.Background()); ok {
+		t.Fatal("want no scrape id")
+	}
+
+	ctx := WithScrapeID(context.Background(), "abc")
+	if id, ok := ScrapeIDFromContext(ctx); !ok || id != "abc" {
+		t.Fatalf("want scrape id %q; got %q", "abc", id)
+	}
+}
@@ -0,0 +1,191 @@
+package fluentbit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultScraperConcurrency is the number of targets scraped at once.
+	DefaultScraperConcurrency = 8
+	// DefaultScraperInterval is the time between scrape rounds.
+	DefaultScraperInterval = 15 * time.Second
+)
+
+// ScraperOptions tunes a Scraper.
+// Zero values take the package defaults.
+type ScraperOptions struct {
+	Concurrency int
+	// RateLimit, if positive, caps the requests per second
+	// across all targets.
+	RateLimit float64
+	Interval  time.Duration
+	// ServedBy records the address of the instance
+	// that served each scrape into ScrapeResult.ServedBy.
+	ServedBy bool
+}
+
+// ScrapeResult is the outcome of scraping one target.
+type ScrapeResult struct {
+	// Target is the scraped client base URL.
+	Target string
+	// Time is when the metrics were sampled: the scrape time,
+	// minus Age when served from a cache.
+	Time    time.Time
+	Metrics Metrics
+	// Age is the Age header of a response served by a caching proxy,
+	// see WithAge. Zero otherwise.
+	Age time.Duration
+	// ServedBy is the remote address that served the scrape,
+	// only recorded with ScraperOptions.ServedBy, see WithServedBy.
+	ServedBy string
+	// Err is an *InstanceError if the scrape failed.
+	Err error
+}
+
+// Scraper scrapes the Metrics of many Fluent Bit instances on a schedule,
+// for central collectors watching hundreds of them.
+// At most Concurrency targets are scraped at once and at most RateLimit
+// requests are sent per second, so neither the network nor the collector
+// gets overwhelmed.
+type Scraper struct {
+	clients []*Client
+	opts    ScraperOptions
+}
+
+// NewScraper returns a Scraper for the given clients.
+func NewScraper(clients []*Client, opts ScraperOptions) *Scraper {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultScraperConcurrency
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultScraperInterval
+	}
+
+	return &Scraper{clients: clients, opts: opts}
+}
+
+// Run scrapes every target right away and then every Interval,
+// delivering results as they come until ctx is done,
+// at which point the channel is closed.
+// Results are delivered unbuffered: a slow consumer blocks the workers,
+// and a round running late delays the next one rather than overlapping it.
+func (s *Scraper) Run(ctx context.Context) <-chan ScrapeResult {
+	out := make(chan ScrapeResult)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.round(ctx, func(_ int, r ScrapeResult) bool {
+				select {
+				case out <- r:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return out
+}
+
+// ScrapeOnce scrapes every target once and returns their results
+// in the order of the clients. The failed ones are also reported
+// together as ScrapeErrors.
+func (s *Scraper) ScrapeOnce(ctx context.Context) ([]ScrapeResult, error) {
+	out := make([]ScrapeResult, len(s.clients))
+	s.round(ctx, func(i int, r ScrapeResult) bool {
+		out[i] = r
+		return true
+	})
+
+	var errs ScrapeErrors
+	for _, r := range out {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return out, errs.errOrNil()
+}
+
+// round scrapes every target once, handing each result along with
+// the index of its client to emit. Once emit returns false
+// the remaining targets fail right away.
+func (s *Scraper) round(ctx context.Context, emit func(i int, r ScrapeResult) bool) {
+	s.each(ctx, func(ctx context.Context, i int) bool {
+		return emit(i, scrape(ctx, s.clients[i], s.opts.ServedBy))
+	})
+}
+
+// each calls fn with the index of every client, at most Concurrency
+// at once and at most RateLimit per second. Once fn returns false
+// the context of the remaining calls is canceled.
+func (s *Scraper) each(ctx context.Context, fn func(ctx context.Context, i int) bool) {
+	var limit <-chan time.Time
+	if s.opts.RateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / s.opts.RateLimit))
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if !fn(ctx, i) {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range s.clients {
+		if limit != nil && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-limit:
+			}
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func scrape(ctx context.Context, c *Client, servedBy bool) ScrapeResult {
+	r := ScrapeResult{Target: c.BaseURL, Time: time.Now()}
+	if err := ctx.Err(); err != nil {
+		r.Err = &InstanceError{BaseURL: c.BaseURL, Err: err}
+		return r
+	}
+
+	if servedBy {
+		ctx = WithServedBy(ctx, &r.ServedBy)
+	}
+
+	mm, err := c.Metrics(WithAge(ctx, &r.Age))
+	if err != nil {
+		r.Err = &InstanceError{BaseURL: c.BaseURL, Err: err}
+		return r
+	}
+
+	r.Time = r.Time.Add(-r.Age)
+	r.Metrics = mm
+	return r
+}
@@ -0,0 +1,121 @@
+package fluentbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScraper_ScrapeOnce(t *testing.T) {
+	var inFlight, maxInFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if r.URL.Path == "/broken/api/v1/metrics" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	var clients []*Client
+	for _, prefix := range []string{"/a", "/broken", "/b", "/c", "/d"} {
+		clients = append(clients, &Client{
+			HTTPClient: srv.Client(),
+			BaseURL:    srv.URL + prefix,
+		})
+	}
+
+	scraper := NewScraper(clients, ScraperOptions{Concurrency: 2})
+	results, err := scraper.ScrapeOnce(context.Background())
+
+	var errs ScrapeErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("want a single scrape error; got %v", err)
+	}
+
+	var ie *InstanceError
+	if !errors.As(err, &ie) || ie.BaseURL != srv.URL+"/broken" {
+		t.Fatalf("want instance error for the broken target; got %v", err)
+	}
+
+	for i, r := range results {
+		if want, got := clients[i].BaseURL, r.Target; want != got {
+			t.Fatalf("want result %d for %s; got %s", i, want, got)
+		}
+		if i != 1 && (r.Err != nil || r.Metrics.Input["cpu.0"].Records != 1) {
+			t.Fatalf("want metrics for %s; got %+v", r.Target, r)
+		}
+	}
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Fatalf("want at most 2 concurrent scrapes; got %d", got)
+	}
+}
+
+func TestScraper_rateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	var clients []*Client
+	for i := 0; i < 5; i++ {
+		clients = append(clients, &Client{HTTPClient: srv.Client(), BaseURL: srv.URL})
+	}
+
+	scraper := NewScraper(clients, ScraperOptions{Concurrency: 5, RateLimit: 50})
+	start := time.Now()
+	if _, err := scraper.ScrapeOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 requests at 50 per second.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("want rate limited scrapes; took %s", elapsed)
+	}
+}
+
+func TestScraper_Run(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	clients := []*Client{
+		{HTTPClient: srv.Client(), BaseURL: srv.URL},
+		{HTTPClient: srv.Client(), BaseURL: srv.URL},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := NewScraper(clients, ScraperOptions{Interval: 10 * time.Millisecond}).Run(ctx)
+
+	// two rounds, read slowly.
+	for i := 0; i < 4; i++ {
+		r := <-results
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,35 @@
+package fluentbit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+)
+
+type servedByKey struct{}
+
+// WithServedBy returns a copy of ctx making the client store in addr
+// the remote address, e.g. "10.0.0.7:2020", of the connection that
+// served the last attempt of requests made with it.
+// When BaseURL is a DNS name load balancing many Fluent Bit instances,
+// it tells which one answered, to diagnose metrics jumping around.
+// Nothing is traced for contexts not carrying it.
+func WithServedBy(ctx context.Context, addr *string) context.Context {
+	return context.WithValue(ctx, servedByKey{}, addr)
+}
+
+// traceServedBy installs the trace recording the served by address
+// if the request context asks for it.
+func traceServedBy(req *http.Request) *http.Request {
+	addr, ok := req.Context().Value(servedByKey{}).(*string)
+	if !ok || addr == nil {
+		return req
+	}
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			*addr = info.Conn.RemoteAddr().String()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
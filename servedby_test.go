@@ -0,0 +1,48 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithServedBy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{},"filter":{},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	var addr string
+	if _, err := client.Metrics(WithServedBy(context.Background(), &addr)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := srv.Listener.Addr().String(), addr; want != got {
+		t.Fatalf("want served by %q; got %q", want, got)
+	}
+
+	results, err := NewScraper([]*Client{client}, ScraperOptions{ServedBy: true}).ScrapeOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := srv.Listener.Addr().String(), results[0].ServedBy; want != got {
+		t.Fatalf("want scrape served by %q; got %q", want, got)
+	}
+
+	results, err = NewScraper([]*Client{client}, ScraperOptions{}).ScrapeOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := results[0].ServedBy; got != "" {
+		t.Fatalf("want no served by address by default; got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package fluentbit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseSize parses the human readable sizes Fluent Bit reports,
+// like "0b", "512b", "1.5K" or "30.2M", into bytes.
+// Units are powers of 1024 and case insensitive, with an optional "B" suffix
+// so "1.5KB" is accepted as well. A number without unit is taken as bytes.
+// Negative sizes are an error.
+func ParseSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	num := strings.TrimRightFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	unit := strings.ToUpper(strings.TrimSpace(s[len(num):]))
+	if unit != "B" {
+		unit = strings.TrimSuffix(unit, "B")
+	}
+
+	var mult float64
+	switch unit {
+	case "", "B":
+		mult = 1
+	case "K":
+		mult = 1 << 10
+	case "M":
+		mult = 1 << 20
+	case "G":
+		mult = 1 << 30
+	case "T":
+		mult = 1 << 40
+	case "P":
+		mult = 1 << 50
+	default:
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("invalid size %q: negative", s)
+	}
+
+	bytes := v * mult
+	if bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %q: out of range", s)
+	}
+
+	return uint64(math.Round(bytes)), nil
+}
@@ -0,0 +1,37 @@
+package fluentbit
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tt := []struct {
+		in   string
+		want uint64
+	}{
+		{"0b", 0},
+		{"512b", 512},
+		{"1.0K", 1024},
+		{"1.5K", 1536},
+		{"2.0M", 2 << 20},
+		{"1G", 1 << 30},
+		{"5MB", 5 << 20},
+		{"100", 100},
+		{" 1k ", 1024},
+	}
+	for _, tc := range tt {
+		got, err := ParseSize(tc.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tc.in, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("ParseSize(%q) want %d; got %d", tc.in, tc.want, got)
+		}
+	}
+
+	for _, in := range []string{"", "K", "1.2X", "abc", "1e999", "-1", "-1K"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) want error", in)
+		}
+	}
+}
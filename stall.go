@@ -0,0 +1,48 @@
+package fluentbit
+
+// PipelineStalled reports whether the whole pipeline is stuck between
+// two snapshots: inputs ingested records while no output processed any.
+//
+// It is deliberately conservative and reports false when:
+//   - curr has no outputs, since there is nothing that could flush.
+//   - no input advanced, since an idle pipeline isn't a stalled one.
+//   - any input or output counter went down, since after a restart
+//     or hot reload the snapshots can't be compared.
+//
+// Only plugins present in both snapshots are compared.
+func PipelineStalled(prev, curr Metrics) bool {
+	var outputs int
+	for name, c := range curr.Output {
+		p, ok := prev.Output[name]
+		if !ok {
+			continue
+		}
+
+		if c.ProcRecords != p.ProcRecords {
+			// either flushing or reset.
+			return false
+		}
+		outputs++
+	}
+
+	if outputs == 0 {
+		return false
+	}
+
+	var advanced bool
+	for name, c := range curr.Input {
+		p, ok := prev.Input[name]
+		if !ok {
+			continue
+		}
+
+		if c.Records < p.Records {
+			return false
+		}
+		if c.Records > p.Records {
+			advanced = true
+		}
+	}
+
+	return advanced
+}
@@ -0,0 +1,40 @@
+package fluentbit
+
+import "testing"
+
+func TestPipelineStalled(t *testing.T) {
+	metrics := func(in, out uint64) Metrics {
+		return Metrics{
+			Input:  map[string]MetricInput{"cpu.0": {Records: in}},
+			Output: map[string]MetricOutput{"stdout.0": {ProcRecords: out}, "http.1": {ProcRecords: out}},
+		}
+	}
+
+	tt := []struct {
+		name       string
+		prev, curr Metrics
+		want       bool
+	}{
+		{"stalled", metrics(10, 5), metrics(20, 5), true},
+		{"flushing", metrics(10, 5), metrics(20, 15), false},
+		{"idle", metrics(10, 10), metrics(10, 10), false},
+		{"input_reset", metrics(10, 5), metrics(2, 5), false},
+		{"output_reset", metrics(10, 5), metrics(20, 1), false},
+		{"no_outputs", Metrics{
+			Input: map[string]MetricInput{"cpu.0": {Records: 10}},
+		}, Metrics{
+			Input: map[string]MetricInput{"cpu.0": {Records: 20}},
+		}, false},
+		{"one_output_flushing", metrics(10, 5), Metrics{
+			Input:  map[string]MetricInput{"cpu.0": {Records: 20}},
+			Output: map[string]MetricOutput{"stdout.0": {ProcRecords: 5}, "http.1": {ProcRecords: 6}},
+		}, false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PipelineStalled(tc.prev, tc.curr); tc.want != got {
+				t.Fatalf("want stalled %v; got %v", tc.want, got)
+			}
+		})
+	}
+}
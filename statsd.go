@@ -0,0 +1,112 @@
+package fluentbit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StatsDMode selects how StatsDWriter reports counters.
+type StatsDMode int
+
+const (
+	// StatsDGauge reports the raw cumulative value as a gauge ("|g").
+	// Use it when the StatsD server should keep the latest value as is.
+	StatsDGauge StatsDMode = iota
+	// StatsDDelta reports the increase since the previous write as a counter ("|c").
+	// This is the mode that matches StatsD counter semantics,
+	// since StatsD sums counter samples within each flush interval.
+	StatsDDelta
+)
+
+// StatsDWriter writes metrics in StatsD line format,
+// like "fluentbit.output.stdout.0.proc_records:123|g".
+// Namer, if set, names the metrics instead of StatsDNamer,
+// Prefix is prepended to its names either way.
+// Labels, if set, are appended to every line as DogStatsD tags,
+// like "|#cluster:eu-1,tenant:acme"; see ValidateLabels for the valid names.
+// In StatsDDelta mode it remembers the last written metrics
+// and so it is not safe for concurrent use.
+type StatsDWriter struct {
+	Prefix string
+	Mode   StatsDMode
+	Labels map[string]string
+	Namer  MetricNamer
+
+	last map[string]uint64
+}
+
+// WriteStatsD writes the raw counter values of m as StatsD gauges.
+func (m Metrics) WriteStatsD(w io.Writer, prefix string) error {
+	sw := StatsDWriter{Prefix: prefix, Mode: StatsDGauge}
+	return sw.Write(w, m)
+}
+
+// Write writes m to w. In StatsDDelta mode the first write
+// only records the values, and counters that decreased,
+// like after a restart, report their current value.
+func (sw *StatsDWriter) Write(w io.Writer, m Metrics) error {
+	if err := ValidateLabels(sw.Labels); err != nil {
+		return err
+	}
+
+	tags := statsDTags(sw.Labels)
+	namer := namerOrDefault(sw.Namer, StatsDNamer)
+	bw := bufio.NewWriter(w)
+	next := map[string]uint64{}
+	for _, c := range m.counters() {
+		name := statsDName(sw.Prefix, namer.name(c))
+		switch sw.Mode {
+		case StatsDDelta:
+			next[name] = c.value
+			if sw.last == nil {
+				continue
+			}
+
+			prev, ok := sw.last[name]
+			v := c.value
+			if ok && c.value >= prev {
+				v = c.value - prev
+			}
+			fmt.Fprintf(bw, "%s:%d|c%s\n", name, v, tags)
+		default:
+			fmt.Fprintf(bw, "%s:%d|g%s\n", name, c.value, tags)
+		}
+	}
+
+	if sw.Mode == StatsDDelta {
+		sw.last = next
+	}
+
+	return bw.Flush()
+}
+
+var statsDReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", " ", "_", "\n", "_")
+
+func statsDName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+var statsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", " ", "_", "\n", "_")
+
+func statsDTags(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("|#")
+	for i, l := range sortedLabels(labels) {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l[0])
+		sb.WriteByte(':')
+		sb.WriteString(statsDTagReplacer.Replace(l[1]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package fluentbit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMetrics_WriteStatsD(t *testing.T) {
+	mm := Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 1, Bytes: 10},
+		},
+		Output: map[string]MetricOutput{
+			"my out": {ProcRecords: 123},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := mm.WriteStatsD(&buf, "fluentbit"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `fluentbit.input.cpu.0.records:1|g
+fluentbit.input.cpu.0.bytes:10|g
+fluentbit.output.my_out.proc_records:123|g
+fluentbit.output.my_out.proc_bytes:0|g
+fluentbit.output.my_out.errors:0|g
+fluentbit.output.my_out.retries:0|g
+fluentbit.output.my_out.retries_failed:0|g
+fluentbit.output.my_out.dropped_records:0|g
+fluentbit.output.my_out.retried_records:0|g
+`
+	if got := buf.String(); want != got {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestStatsDWriter_delta(t *testing.T) {
+	sw := &StatsDWriter{Mode: StatsDDelta}
+	write := func(records uint64) string {
+		var buf bytes.Buffer
+		err := sw.Write(&buf, Metrics{
+			Input: map[string]MetricInput{
+				"cpu.0": {Records: records},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if got := write(10); got != "" {
+		t.Fatalf("want first write to be empty; got %q", got)
+	}
+
+	if want, got := "input.cpu.0.records:5|c\ninput.cpu.0.bytes:0|c\n", write(15); want != got {
+		t.Fatalf("want %q; got %q", want, got)
+	}
+
+	// restart.
+	if want, got := "input.cpu.0.records:3|c\ninput.cpu.0.bytes:0|c\n", write(3); want != got {
+		t.Fatalf("want %q; got %q", want, got)
+	}
+}
+
+func TestStatsDWriter_labels(t *testing.T) {
+	sw := &StatsDWriter{Labels: map[string]string{"tenant": "acme", "cluster": "eu 1"}}
+
+	var buf bytes.Buffer
+	err := sw.Write(&buf, Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 1, Bytes: 10},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "input.cpu.0.records:1|g|#cluster:eu_1,tenant:acme\ninput.cpu.0.bytes:10|g|#cluster:eu_1,tenant:acme\n"
+	if got := buf.String(); want != got {
+		t.Fatalf("want %q; got %q", want, got)
+	}
+
+	sw.Labels = map[string]string{"bad-name": "x"}
+	if err := sw.Write(&buf, Metrics{}); err == nil {
+		t.Fatal("want invalid label error")
+	}
+}
+
+func TestStatsDWriter_Namer(t *testing.T) {
+	sw := &StatsDWriter{
+		Prefix: "fb",
+		Namer: func(section, plugin, field string) string {
+			return section + "_" + field
+		},
+	}
+
+	var buf bytes.Buffer
+	err := sw.Write(&buf, Metrics{
+		Input: map[string]MetricInput{
+			"cpu.0": {Records: 1, Bytes: 10},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "fb.input_records:1|g\nfb.input_bytes:10|g\n", buf.String(); want != got {
+		t.Fatalf("want %q; got %q", want, got)
+	}
+}
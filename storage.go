@@ -0,0 +1,323 @@
+package fluentbit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DecodeStorageMetrics decodes a GET /api/v1/storage payload.
+func DecodeStorageMetrics(raw []byte) (StorageMetrics, error) {
+	var sm StorageMetrics
+	if err := json.Unmarshal(raw, &sm); err != nil {
+		return StorageMetrics{}, fmt.Errorf("could not decode storage metrics: %w", err)
+	}
+
+	return sm, nil
+}
+
+// StorageDelta is the change between two StorageMetrics snapshots.
+// Growing FsChunksDown or per-input BusySize signals a developing backlog.
+type StorageDelta struct {
+	TotalChunks  int64
+	MemChunks    int64
+	FsChunks     int64
+	FsChunksUp   int64
+	FsChunksDown int64
+
+	// Inputs keyed by input name.
+	Inputs map[string]InputStorageDelta
+}
+
+type InputStorageDelta struct {
+	TotalChunks int64
+	UpChunks    int64
+	DownChunks  int64
+	BusyChunks  int64
+	// BusySize is the change in busy chunks size in bytes.
+	BusySize int64
+
+	// Added is set when the input is missing from the previous snapshot
+	// and Removed when it's missing from the current one.
+	// Missing inputs count as zero.
+	Added   bool
+	Removed bool
+}
+
+// StorageDiff computes the change from prev to curr.
+// It fails if a busy_size can't be parsed.
+func StorageDiff(prev, curr StorageMetrics) (StorageDelta, error) {
+	pc, cc := prev.StorageLayer.Chunks, curr.StorageLayer.Chunks
+	out := StorageDelta{
+		TotalChunks:  delta(pc.TotalChunks, cc.TotalChunks),
+		MemChunks:    delta(pc.MemChunks, cc.MemChunks),
+		FsChunks:     delta(pc.FsChunks, cc.FsChunks),
+		FsChunksUp:   delta(pc.FsChunksUp, cc.FsChunksUp),
+		FsChunksDown: delta(pc.FsChunksDown, cc.FsChunksDown),
+		Inputs:       map[string]InputStorageDelta{},
+	}
+
+	for name, c := range curr.InputChunks {
+		p, ok := prev.InputChunks[name]
+		d, err := inputStorageDiff(name, p, c)
+		if err != nil {
+			return StorageDelta{}, err
+		}
+
+		d.Added = !ok
+		out.Inputs[name] = d
+	}
+
+	for name, p := range prev.InputChunks {
+		if _, ok := curr.InputChunks[name]; ok {
+			continue
+		}
+
+		d, err := inputStorageDiff(name, p, PluginStorage{})
+		if err != nil {
+			return StorageDelta{}, err
+		}
+
+		d.Removed = true
+		out.Inputs[name] = d
+	}
+
+	return out, nil
+}
+
+func inputStorageDiff(name string, prev, curr PluginStorage) (InputStorageDelta, error) {
+	prevBusy, err := parseOptionalSize(prev.Chunks.BusySize)
+	if err != nil {
+		return InputStorageDelta{}, fmt.Errorf("input %q previous busy_size: %w", name, err)
+	}
+
+	currBusy, err := parseOptionalSize(curr.Chunks.BusySize)
+	if err != nil {
+		return InputStorageDelta{}, fmt.Errorf("input %q current busy_size: %w", name, err)
+	}
+
+	return InputStorageDelta{
+		TotalChunks: delta(prev.Chunks.Total, curr.Chunks.Total),
+		UpChunks:    delta(prev.Chunks.Up, curr.Chunks.Up),
+		DownChunks:  delta(prev.Chunks.Down, curr.Chunks.Down),
+		BusyChunks:  delta(prev.Chunks.Busy, curr.Chunks.Busy),
+		BusySize:    delta(prevBusy, currBusy),
+	}, nil
+}
+
+// parseOptionalSize is like ParseSize but takes an empty size as zero.
+func parseOptionalSize(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return ParseSize(s)
+}
+
+func delta(prev, curr uint64) int64 {
+	return int64(curr) - int64(prev)
+}
+
+// PausedInputs returns the sorted names of the inputs whose ingestion is paused.
+// Fluent Bit doesn't report the paused state itself, but it pauses an input
+// once it goes over its mem_buf_limit, so overlimit inputs are taken as paused.
+func (s StorageMetrics) PausedInputs() []string {
+	var out []string
+	for name, p := range s.InputChunks {
+		if p.IsPaused() {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsPaused reports whether the input is paused by being overlimit,
+// see PausedInputs.
+func (p PluginStorage) IsPaused() bool {
+	return p.Status.Overlimit
+}
+
+// TotalChunks returns the number of chunks of the input.
+// The per-input payload names it "total" while the storage layer
+// names its own total "total_chunks"; both decode the same across versions.
+func (p PluginStorage) TotalChunks() uint64 {
+	return p.Chunks.Total
+}
+
+// MemUtilization returns the fraction of its mem_buf_limit the input uses,
+// that is MemSize over MemLimit, e.g. 0.87 for 87%.
+// It can go above 1 since an input may overshoot its limit before pausing.
+// It returns zero when the input has no limit set, reported as "0b".
+// It fails if either size can't be parsed.
+func (p PluginStorage) MemUtilization() (float64, error) {
+	limit, err := parseOptionalSize(p.Status.MemLimit)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse mem_limit: %w", err)
+	}
+
+	if limit == 0 {
+		return 0, nil
+	}
+
+	size, err := parseOptionalSize(p.Status.MemSize)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse mem_size: %w", err)
+	}
+
+	return float64(size) / float64(limit), nil
+}
+
+// MemSize returns the total size in bytes of the chunks the inputs hold
+// in memory. The storage_layer section only reports chunk counts,
+// with no size, in every version, so this sums the inputs mem_size.
+// It fails if a mem_size can't be parsed.
+func (s StorageMetrics) MemSize() (uint64, error) {
+	var total uint64
+	for name, p := range s.InputChunks {
+		size, err := parseOptionalSize(p.Status.MemSize)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %s mem_size: %w", name, err)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// BusySize returns the total size in bytes of the chunks being flushed,
+// summing the inputs busy_size.
+// It fails if a busy_size can't be parsed.
+func (s StorageMetrics) BusySize() (uint64, error) {
+	var total uint64
+	for name, p := range s.InputChunks {
+		size, err := parseOptionalSize(p.Chunks.BusySize)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %s busy_size: %w", name, err)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// FsChunkRatio returns the fraction of chunks backed by the filesystem,
+// fs_chunks over total_chunks, or zero when there are no chunks.
+// A ratio near 1 is expected with storage.type filesystem inputs; a rising
+// one with memory inputs means data is spilling to disk under backpressure.
+// Read it along with FsChunksDown: a high ratio with growing down chunks
+// means data is stuck on disk waiting for outputs, and overlimit inputs,
+// see PausedInputs, mean memory is already exhausted.
+func (s StorageMetrics) FsChunkRatio() float64 {
+	chunks := s.StorageLayer.Chunks
+	if chunks.TotalChunks == 0 {
+		return 0
+	}
+
+	return float64(chunks.FsChunks) / float64(chunks.TotalChunks)
+}
+
+// decodeStorageMetrics decodes a storage payload into sm token by token,
+// keeping at most c.MaxInputChunks input_chunks entries.
+func (c *Client) decodeStorageMetrics(sm *StorageMetrics) func(io.Reader) error {
+	return func(r io.Reader) error {
+		if c.ResponseEnvelope != "" {
+			inner, err := unwrapEnvelope(r, c.ResponseEnvelope)
+			if err != nil {
+				return err
+			}
+			r = bytes.NewReader(inner)
+		}
+
+		if err := decodeStorageMetricsStream(json.NewDecoder(r), sm, c.MaxInputChunks); err != nil {
+			return fmt.Errorf("could not json unmarshal response: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func decodeStorageMetricsStream(dec *json.Decoder, sm *StorageMetrics, maxInputChunks int) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "storage_layer":
+			err = dec.Decode(&sm.StorageLayer)
+		case "input_chunks":
+			err = decodeInputChunks(dec, sm, maxInputChunks)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+func decodeInputChunks(dec *json.Decoder, sm *StorageMetrics, limit int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok == nil {
+		return nil
+	}
+
+	if tok != json.Delim('{') {
+		return fmt.Errorf("unexpected input_chunks token %v", tok)
+	}
+
+	sm.InputChunks = map[string]PluginStorage{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		name, _ := key.(string)
+		if len(sm.InputChunks) >= limit {
+			// each skipped entry is held in memory only while skipping it.
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+
+			sm.Truncated = true
+			continue
+		}
+
+		var p PluginStorage
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+
+		sm.InputChunks[name] = p
+	}
+
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("unexpected token %v, want %v", tok, delim)
+	}
+
+	return nil
+}
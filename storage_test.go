@@ -0,0 +1,265 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func newPluginStorage(total, up, down, busy uint64, busySize string) PluginStorage {
+	var p PluginStorage
+	p.Chunks.Total = total
+	p.Chunks.Up = up
+	p.Chunks.Down = down
+	p.Chunks.Busy = busy
+	p.Chunks.BusySize = busySize
+	return p
+}
+
+func TestStorageDiff(t *testing.T) {
+	var prev, curr StorageMetrics
+	prev.StorageLayer.Chunks.TotalChunks = 2
+	prev.StorageLayer.Chunks.FsChunksDown = 1
+	prev.InputChunks = map[string]PluginStorage{
+		"cpu.0": newPluginStorage(1, 1, 0, 1, "1.0K"),
+		"mem.1": newPluginStorage(1, 1, 0, 0, "0b"),
+	}
+
+	curr.StorageLayer.Chunks.TotalChunks = 5
+	curr.StorageLayer.Chunks.FsChunksDown = 3
+	curr.InputChunks = map[string]PluginStorage{
+		"cpu.0":  newPluginStorage(3, 1, 2, 2, "3.0K"),
+		"tail.2": newPluginStorage(2, 2, 0, 1, "512b"),
+	}
+
+	got, err := StorageDiff(prev, curr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := StorageDelta{
+		TotalChunks:  3,
+		FsChunksDown: 2,
+		Inputs: map[string]InputStorageDelta{
+			"cpu.0":  {TotalChunks: 2, DownChunks: 2, BusyChunks: 1, BusySize: 2048},
+			"tail.2": {TotalChunks: 2, UpChunks: 2, BusyChunks: 1, BusySize: 512, Added: true},
+			"mem.1":  {TotalChunks: -1, UpChunks: -1, Removed: true},
+		},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want delta %+v; got %+v", want, got)
+	}
+
+	curr.InputChunks["cpu.0"] = newPluginStorage(0, 0, 0, 0, "bogus")
+	if _, err := StorageDiff(prev, curr); err == nil {
+		t.Fatal("want error on invalid busy_size")
+	}
+}
+
+func TestStorageMetrics_PausedInputs(t *testing.T) {
+	raw, err := os.ReadFile("testdata/storage_v1_8.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sm StorageMetrics
+	if err := json.Unmarshal(raw, &sm); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := []string{"tail.1"}, sm.PausedInputs(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("want paused inputs %v; got %v", want, got)
+	}
+
+	if sm.InputChunks["cpu.0"].IsPaused() {
+		t.Fatal("want cpu.0 not paused while under its limit")
+	}
+}
+
+func TestDecodeStorageMetrics_totalChunks(t *testing.T) {
+	raw, err := os.ReadFile("testdata/storage_v1_8.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm, err := DecodeStorageMetrics(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(3), sm.StorageLayer.Chunks.TotalChunks; want != got {
+		t.Fatalf("want storage layer total chunks %d; got %d", want, got)
+	}
+
+	var sum uint64
+	for _, p := range sm.InputChunks {
+		sum += p.TotalChunks()
+	}
+	if want, got := sm.StorageLayer.Chunks.TotalChunks, sum; want != got {
+		t.Fatalf("want input total chunks to add up to %d; got %d", want, got)
+	}
+
+	if want, got := uint64(2), sm.InputChunks["tail.1"].TotalChunks(); want != got {
+		t.Fatalf("want tail.1 total chunks %d; got %d", want, got)
+	}
+}
+
+func TestPluginStorage_MemUtilization(t *testing.T) {
+	tt := []struct {
+		name    string
+		size    string
+		limit   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "half", size: "2.5M", limit: "5.0M", want: 0.5},
+		{name: "over", size: "6M", limit: "4M", want: 1.5},
+		{name: "unlimited", size: "1.2K", limit: "0b", want: 0},
+		{name: "unset", size: "1.2K", limit: "", want: 0},
+		{name: "bad_size", size: "lots", limit: "5.0M", wantErr: true},
+		{name: "bad_limit", size: "1K", limit: "lots", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var p PluginStorage
+			p.Status.MemSize = tc.size
+			p.Status.MemLimit = tc.limit
+
+			got, err := p.MemUtilization()
+			if gotErr := err != nil; tc.wantErr != gotErr {
+				t.Fatalf("want error %v; got %v", tc.wantErr, err)
+			}
+			if tc.want != got {
+				t.Fatalf("want mem utilization %v; got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStorageMetrics_sizes(t *testing.T) {
+	raw, err := os.ReadFile("testdata/storage_v1_8.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the storage_layer section only reports chunk counts.
+	var top struct {
+		StorageLayer map[string]map[string]json.RawMessage `json:"storage_layer"`
+	}
+	if err := json.Unmarshal(raw, &top); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(top.StorageLayer); want != got {
+		t.Fatalf("want %d storage_layer section; got %d", want, got)
+	}
+
+	sm, err := DecodeStorageMetrics(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1.2K + 5.0M + 0b, rounded to the byte.
+	memSize, err := sm.MemSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(1229+5*1024*1024), memSize; want != got {
+		t.Fatalf("want mem size %d; got %d", want, got)
+	}
+
+	busySize, err := sm.BusySize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(2202010), busySize; want != got {
+		t.Fatalf("want busy size %d; got %d", want, got)
+	}
+
+	sm.InputChunks["cpu.0"] = newPluginStorage(0, 0, 0, 0, "lots")
+	if _, err := sm.BusySize(); err == nil {
+		t.Fatal("want error on invalid busy_size")
+	}
+}
+
+func TestStorageMetrics_FsChunkRatio(t *testing.T) {
+	var sm StorageMetrics
+	if got := sm.FsChunkRatio(); got != 0 {
+		t.Fatalf("want zero ratio without chunks; got %v", got)
+	}
+
+	sm.StorageLayer.Chunks.TotalChunks = 8
+	sm.StorageLayer.Chunks.MemChunks = 2
+	sm.StorageLayer.Chunks.FsChunks = 6
+	if want, got := 0.75, sm.FsChunkRatio(); want != got {
+		t.Fatalf("want ratio %v; got %v", want, got)
+	}
+}
+
+func TestClient_MaxInputChunks(t *testing.T) {
+	raw, err := os.ReadFile("testdata/storage_v1_8.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := DecodeStorageMetrics(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := raw
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient:     srv.Client(),
+		BaseURL:        srv.URL,
+		MaxInputChunks: 2,
+	}
+
+	ctx := context.Background()
+	sm, err := client.StorageMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !sm.Truncated {
+		t.Fatal("want truncated storage metrics")
+	}
+
+	if want, got := 2, len(sm.InputChunks); want != got {
+		t.Fatalf("want %d input chunks; got %d", want, got)
+	}
+
+	for name, p := range sm.InputChunks {
+		if !reflect.DeepEqual(full.InputChunks[name], p) {
+			t.Fatalf("want %s storage %+v; got %+v", name, full.InputChunks[name], p)
+		}
+	}
+
+	if want, got := full.StorageLayer, sm.StorageLayer; want != got {
+		t.Fatalf("want storage layer %+v; got %+v", want, got)
+	}
+
+	client.MaxInputChunks = len(full.InputChunks)
+	sm, err = client.StorageMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(full, sm) {
+		t.Fatalf("want storage metrics %+v; got %+v", full, sm)
+	}
+
+	body = nil
+	_, err = client.StorageMetrics(ctx)
+	if !errors.Is(err, ErrStorageMetricsDisabled) {
+		t.Fatalf("want error %v; got %v", ErrStorageMetricsDisabled, err)
+	}
+}
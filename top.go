@@ -0,0 +1,74 @@
+package fluentbit
+
+import (
+	"sort"
+	"time"
+)
+
+// RankedPlugin is a plugin instance throughput, as ranked by the Top functions.
+type RankedPlugin struct {
+	Name          string
+	RecordsPerSec float64
+	BytesPerSec   float64
+}
+
+// TopOutputsByThroughput ranks the outputs by processed records per second
+// between two snapshots taken elapsed apart, breaking ties by bytes per second.
+// It returns at most n outputs, and nil for a non-positive n or elapsed.
+func TopOutputsByThroughput(prev, curr Metrics, n int, elapsed time.Duration) []RankedPlugin {
+	if n <= 0 || elapsed <= 0 {
+		return nil
+	}
+
+	var out []RankedPlugin
+	for name, r := range Rate(prev, curr, elapsed).Output {
+		out = append(out, RankedPlugin{Name: name, RecordsPerSec: r.ProcRecords, BytesPerSec: r.ProcBytes})
+	}
+	return topRanked(out, n)
+}
+
+// TopInputsByThroughput is like TopOutputsByThroughput for inputs,
+// ranking them by ingested records per second.
+func TopInputsByThroughput(prev, curr Metrics, n int, elapsed time.Duration) []RankedPlugin {
+	if n <= 0 || elapsed <= 0 {
+		return nil
+	}
+
+	var out []RankedPlugin
+	for name, r := range Rate(prev, curr, elapsed).Input {
+		out = append(out, RankedPlugin{Name: name, RecordsPerSec: r.Records, BytesPerSec: r.Bytes})
+	}
+	return topRanked(out, n)
+}
+
+// TopFiltersByThroughput is like TopOutputsByThroughput for filters,
+// ranking them by dropped, added and emitted records per second.
+// Filters don't report bytes so BytesPerSec is always zero.
+func TopFiltersByThroughput(prev, curr Metrics, n int, elapsed time.Duration) []RankedPlugin {
+	if n <= 0 || elapsed <= 0 {
+		return nil
+	}
+
+	var out []RankedPlugin
+	for name, r := range Rate(prev, curr, elapsed).Filter {
+		out = append(out, RankedPlugin{Name: name, RecordsPerSec: r.DropRecords + r.AddRecords + r.EmitRecords})
+	}
+	return topRanked(out, n)
+}
+
+func topRanked(rr []RankedPlugin, n int) []RankedPlugin {
+	sort.Slice(rr, func(i, j int) bool {
+		if rr[i].RecordsPerSec != rr[j].RecordsPerSec {
+			return rr[i].RecordsPerSec > rr[j].RecordsPerSec
+		}
+		if rr[i].BytesPerSec != rr[j].BytesPerSec {
+			return rr[i].BytesPerSec > rr[j].BytesPerSec
+		}
+		return rr[i].Name < rr[j].Name
+	})
+
+	if len(rr) > n {
+		rr = rr[:n]
+	}
+	return rr
+}
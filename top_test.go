@@ -0,0 +1,75 @@
+package fluentbit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTopOutputsByThroughput(t *testing.T) {
+	prev := Metrics{
+		Output: map[string]MetricOutput{
+			"es.0":      {},
+			"forward.1": {},
+			"http.2":    {},
+		},
+	}
+	curr := Metrics{
+		Output: map[string]MetricOutput{
+			"es.0":      {ProcRecords: 10, ProcBytes: 100},
+			"forward.1": {ProcRecords: 20, ProcBytes: 200},
+			"http.2":    {ProcRecords: 10, ProcBytes: 500},
+		},
+	}
+
+	got := TopOutputsByThroughput(prev, curr, 2, time.Second)
+	want := []RankedPlugin{
+		{Name: "forward.1", RecordsPerSec: 20, BytesPerSec: 200},
+		{Name: "http.2", RecordsPerSec: 10, BytesPerSec: 500},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want top outputs %+v; got %+v", want, got)
+	}
+
+	if got := TopOutputsByThroughput(prev, curr, 10, time.Second); len(got) != 3 {
+		t.Fatalf("want all 3 outputs; got %d", len(got))
+	}
+
+	if got := TopOutputsByThroughput(prev, curr, 2, 0); got != nil {
+		t.Fatalf("want nil on zero elapsed; got %+v", got)
+	}
+}
+
+func TestTopInputsByThroughput(t *testing.T) {
+	prev := Metrics{Input: map[string]MetricInput{"cpu.0": {}, "tail.1": {}}}
+	curr := Metrics{Input: map[string]MetricInput{
+		"cpu.0":  {Records: 4, Bytes: 40},
+		"tail.1": {Records: 8, Bytes: 80},
+	}}
+
+	got := TopInputsByThroughput(prev, curr, 1, 2*time.Second)
+	want := []RankedPlugin{{Name: "tail.1", RecordsPerSec: 4, BytesPerSec: 40}}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want top inputs %+v; got %+v", want, got)
+	}
+}
+
+func TestTopFiltersByThroughput(t *testing.T) {
+	prev := Metrics{Filter: map[string]MetricFilter{"grep.0": {}, "lua.1": {}, "rewrite_tag.2": {}}}
+	curr := Metrics{Filter: map[string]MetricFilter{
+		"grep.0":        {DropRecords: 5},
+		"lua.1":         {DropRecords: 1, AddRecords: 2},
+		"rewrite_tag.2": {DropRecords: 1, EmitRecords: 6},
+	}}
+
+	// rewrite_tag.2 only ranks first by its emitted records.
+	got := TopFiltersByThroughput(prev, curr, 5, time.Second)
+	want := []RankedPlugin{
+		{Name: "rewrite_tag.2", RecordsPerSec: 7},
+		{Name: "grep.0", RecordsPerSec: 5},
+		{Name: "lua.1", RecordsPerSec: 3},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want top filters %+v; got %+v", want, got)
+	}
+}
@@ -0,0 +1,118 @@
+package fluentbit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is well above the net/http default of 2,
+	// which causes connection churn when scraping a single instance
+	// frequently or concurrently.
+	DefaultMaxIdleConnsPerHost = 16
+	// DefaultIdleConnTimeout closes pooled connections unused for this long.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportOptions tunes the transport built by NewTransport.
+// Zero values take the package defaults.
+type TransportOptions struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// DialContext, if set, opens the connections instead of net.Dialer,
+	// e.g. to go through an SSH tunnel to an air-gapped host.
+	// It receives the BaseURL host and port as is, so they are
+	// resolved from the dialer's side of the tunnel.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// TLSConfig, if set, is the base TLS configuration for HTTPS base URLs.
+	// It is cloned, and the TLS fields below take precedence over it.
+	// Its InsecureSkipVerify still disables verification, RootCAs included.
+	TLSConfig *tls.Config
+	// ClientCertificates are presented to monitoring endpoints
+	// requiring mutual TLS, added to the ones of TLSConfig.
+	ClientCertificates []tls.Certificate
+	// RootCAs, if set, replaces the pool of TLSConfig and the system one
+	// to verify servers, e.g. with a self-managed CA. See LoadRootCAs.
+	RootCAs *x509.CertPool
+}
+
+// NewTransport returns a copy of http.DefaultTransport
+// with connection pooling tuned for scraping.
+// Each transport keeps at most MaxIdleConnsPerHost idle connections
+// per Fluent Bit instance for IdleConnTimeout.
+func NewTransport(opts TransportOptions) *http.Transport {
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	if t.MaxIdleConns != 0 && t.MaxIdleConns < opts.MaxIdleConnsPerHost {
+		t.MaxIdleConns = opts.MaxIdleConnsPerHost
+	}
+	t.IdleConnTimeout = opts.IdleConnTimeout
+	if opts.DialContext != nil {
+		t.DialContext = opts.DialContext
+	}
+	if opts.TLSConfig != nil || len(opts.ClientCertificates) != 0 || opts.RootCAs != nil {
+		t.TLSClientConfig = tlsConfig(opts)
+	}
+	return t
+}
+
+// NewClient returns a Client for baseURL using
+// an HTTP client built with NewTransport(opts).
+func NewClient(baseURL string, opts TransportOptions) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Transport: NewTransport(opts)},
+		BaseURL:    baseURL,
+	}
+}
+
+// tlsConfig merges the TLS options into a copy of opts.TLSConfig.
+func tlsConfig(opts TransportOptions) *tls.Config {
+	cfg := &tls.Config{}
+	if opts.TLSConfig != nil {
+		cfg = opts.TLSConfig.Clone()
+	}
+
+	if len(opts.ClientCertificates) != 0 {
+		certs := make([]tls.Certificate, 0, len(cfg.Certificates)+len(opts.ClientCertificates))
+		certs = append(certs, cfg.Certificates...)
+		cfg.Certificates = append(certs, opts.ClientCertificates...)
+	}
+
+	if opts.RootCAs != nil {
+		cfg.RootCAs = opts.RootCAs
+	}
+
+	return cfg
+}
+
+// LoadRootCAs reads the PEM encoded certificates at path into a pool
+// suitable for TransportOptions.RootCAs.
+func LoadRootCAs(path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read root CAs: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.New("could not read root CAs: no PEM certificates found")
+	}
+
+	return pool, nil
+}
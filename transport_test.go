@@ -0,0 +1,243 @@
+package fluentbit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	tr := NewTransport(TransportOptions{})
+	if want, got := DefaultMaxIdleConnsPerHost, tr.MaxIdleConnsPerHost; want != got {
+		t.Fatalf("want max idle conns per host %d; got %d", want, got)
+	}
+
+	if want, got := DefaultIdleConnTimeout, tr.IdleConnTimeout; want != got {
+		t.Fatalf("want idle conn timeout %s; got %s", want, got)
+	}
+
+	tr = NewTransport(TransportOptions{MaxIdleConnsPerHost: 200})
+	if want, got := 200, tr.MaxIdleConnsPerHost; want != got {
+		t.Fatalf("want max idle conns per host %d; got %d", want, got)
+	}
+
+	if tr.MaxIdleConns < 200 {
+		t.Fatalf("want max idle conns to fit per host ones; got %d", tr.MaxIdleConns)
+	}
+}
+
+func TestNewClient_DialContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	defer srv.Close()
+
+	var dialed []string
+	client := NewClient("http://fluentbit.internal:2020", TransportOptions{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			// stands for the tunnel.
+			var d net.Dialer
+			return d.DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	})
+
+	up, err := client.UpTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint64(1), up.UpTimeSec; want != got {
+		t.Fatalf("want uptime %d; got %d", want, got)
+	}
+
+	if len(dialed) != 1 || dialed[0] != "fluentbit.internal:2020" {
+		t.Fatalf("want base URL address dialed as is; got %q", dialed)
+	}
+}
+
+func TestNewClient_ClientCertificates(t *testing.T) {
+	ca, caCert := newTestCert(t, nil, nil)
+	clientCert, _ := newTestCert(t, &ca, caCert)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srv.Certificate())
+	base := &tls.Config{RootCAs: rootCAs}
+
+	// failed handshakes are retried until the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	client := NewClient(srv.URL, TransportOptions{TLSConfig: base})
+	if _, err := client.UpTime(ctx); err == nil {
+		t.Fatal("want error without client certificate")
+	}
+
+	ctx = context.Background()
+
+	client = NewClient(srv.URL, TransportOptions{
+		TLSConfig:          base,
+		ClientCertificates: []tls.Certificate{clientCert},
+	})
+	if _, err := client.UpTime(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(base.Certificates) != 0 {
+		t.Fatal("want base TLS config left untouched")
+	}
+}
+
+func TestNewClient_RootCAs(t *testing.T) {
+	ca, caCert := newTestCert(t, nil, nil)
+	serverCert, _ := newTestCert(t, &ca, caCert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uptime_sec":1}`)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})
+	if err := ioutil.WriteFile(path, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCAs, err := LoadRootCAs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RootCAs takes precedence over the pool of the base config.
+	client := NewClient(srv.URL, TransportOptions{
+		TLSConfig: &tls.Config{RootCAs: x509.NewCertPool()},
+		RootCAs:   rootCAs,
+	})
+	if _, err := client.UpTime(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadRootCAs(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("want error for missing file")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := ioutil.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRootCAs(empty); err == nil {
+		t.Fatal("want error for file without certificates")
+	}
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed CA
+// when parent is nil.
+func newTestCert(t *testing.T, parent *tls.Certificate, parentCert *x509.Certificate) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "fluent-bit-metrics test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerCert := interface{}(key), tmpl
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerCert = parent.PrivateKey, parentCert
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
+
+func BenchmarkClient_Metrics_pooling(b *testing.B) {
+	var conns int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"output":{}}`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	clients := []struct {
+		name   string
+		client *Client
+	}{
+		{"default", &Client{
+			HTTPClient: &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+			BaseURL:    srv.URL,
+		}},
+		{"tuned", NewClient(srv.URL, TransportOptions{})},
+	}
+	for _, c := range clients {
+		b.Run(c.name, func(b *testing.B) {
+			atomic.StoreInt64(&conns, 0)
+			b.SetParallelism(2)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := c.client.Metrics(context.Background()); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(atomic.LoadInt64(&conns))/float64(b.N), "conns/op")
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package fluentbit
+
+// Upstream gauges of the output upstream families, labeled by output name.
+const (
+	promOutputUpstreamTotal = "fluentbit_output_upstream_total_connections"
+	promOutputUpstreamBusy  = "fluentbit_output_upstream_busy_connections"
+)
+
+// Upstream holds the connection pool counts of an output using upstream
+// connections, such as forward, http or es.
+type Upstream struct {
+	Total     uint64 `json:"total"`
+	Busy      uint64 `json:"busy"`
+	Available uint64 `json:"available"`
+}
+
+// OutputUpstream returns the upstream connection counts of the named output.
+// It is only available for metrics parsed from Prometheus samples of builds
+// exposing the fluentbit_output_upstream_* gauges, v2.1 onwards; the v1 JSON,
+// older builds and outputs without upstream connections don't report them,
+// in which case it returns false. A pool whose busy connections reach its
+// total is exhausted and stalls the output.
+// The counts are kept in Metrics.Upstream, so they survive JSON round trips.
+func (m Metrics) OutputUpstream(name string) (Upstream, bool) {
+	u, ok := m.Upstream[name]
+	return u, ok
+}
+
+func upstreamsFromPrometheus(samples []PromMetric) map[string]Upstream {
+	out := map[string]Upstream{}
+	for _, s := range samples {
+		name := s.Labels["name"]
+		if name == "" {
+			continue
+		}
+
+		switch s.Name {
+		case promOutputUpstreamTotal:
+			u := out[name]
+			u.Total = promCounter(s.Value)
+			out[name] = u
+		case promOutputUpstreamBusy:
+			u := out[name]
+			u.Busy = promCounter(s.Value)
+			out[name] = u
+		}
+	}
+
+	for name, u := range out {
+		if u.Total > u.Busy {
+			u.Available = u.Total - u.Busy
+		}
+		out[name] = u
+	}
+
+	return out
+}
@@ -0,0 +1,64 @@
+package fluentbit
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetrics_OutputUpstream(t *testing.T) {
+	f, err := os.Open("testdata/prometheus_upstream.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	samples, err := ParsePrometheus(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm := MetricsFromPrometheus(samples)
+
+	tt := []struct {
+		name string
+		want Upstream
+	}{
+		{name: "forward.0", want: Upstream{Total: 4, Busy: 1, Available: 3}},
+		{name: "http.1", want: Upstream{Total: 2, Busy: 2, Available: 0}},
+	}
+	for _, tc := range tt {
+		got, ok := mm.OutputUpstream(tc.name)
+		if !ok {
+			t.Fatalf("want %s upstream", tc.name)
+		}
+
+		if tc.want != got {
+			t.Fatalf("want %s upstream %+v; got %+v", tc.name, tc.want, got)
+		}
+	}
+
+	if _, ok := mm.OutputUpstream("stdout.2"); ok {
+		t.Fatal("want no stdout.2 upstream")
+	}
+
+	if _, ok := (Metrics{}).OutputUpstream("forward.0"); ok {
+		t.Fatal("want no upstream without prometheus samples")
+	}
+
+	raw, err := json.Marshal(TimedMetrics{Time: time.Now(), Metrics: mm})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a reloaded snapshot is the same as the live one.
+	tm, err := DecodeSnapshot(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mm.Output, tm.Metrics.Output) || !reflect.DeepEqual(mm.Upstream, tm.Metrics.Upstream) {
+		t.Fatalf("want %+v after round trip; got %+v", mm, tm.Metrics)
+	}
+}
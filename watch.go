@@ -0,0 +1,69 @@
+package fluentbit
+
+import (
+	"context"
+	"time"
+)
+
+// WatchMetrics scrapes Metrics right away and then every interval,
+// delivering the snapshots and the errors of the failed scrapes,
+// which don't stop the watch, on two unbuffered channels.
+// Snapshots served by a caching proxy are timed back by their Age header,
+// so rates between them stay accurate.
+// A non-positive interval defaults to DefaultScraperInterval.
+//
+// Both channels must be drained: the watch blocks on a send until it is
+// received, so a consumer reading only snapshots stalls at the first
+// failed scrape, and one reading only errors at the first snapshot.
+//
+// Shutdown: once ctx is done, the in-flight scrape, which shares ctx,
+// is aborted and its error dropped, a pending send is abandoned and then
+// both channels are closed, exactly once and by the single goroutine
+// sending on them, so there is no send on a closed channel.
+// Once ctx is done, consumers can range over either channel until it is
+// closed; the watch goroutine has exited by the time both are.
+func (c *Client) WatchMetrics(ctx context.Context, interval time.Duration) (<-chan TimedMetrics, <-chan error) {
+	if interval <= 0 {
+		interval = DefaultScraperInterval
+	}
+
+	snapshots := make(chan TimedMetrics)
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		defer close(snapshots)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			var age time.Duration
+			mm, err := c.Metrics(WithAge(ctx, &age))
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+			} else {
+				select {
+				case snapshots <- TimedMetrics{Time: now.Add(-age), Metrics: mm}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return snapshots, errs
+}
@@ -0,0 +1,131 @@
+package fluentbit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestClient_WatchMetrics(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fail := make(chan bool, 1)
+	fail <- true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-fail:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		default:
+		}
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1}}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+	defer srv.Client().CloseIdleConnections()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	snapshots, errs := client.WatchMetrics(ctx, 10*time.Millisecond)
+	if err := <-errs; err == nil {
+		t.Fatal("want scrape error")
+	}
+
+	s := <-snapshots
+	if want, got := uint64(1), s.Metrics.Input["cpu.0"].Records; want != got {
+		t.Fatalf("want input records %d; got %d", want, got)
+	}
+
+	cancel()
+	waitClosed(t, snapshots, errs)
+}
+
+func TestClient_WatchMetrics_zeroInterval(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"input":{"cpu.0":{"records":1,"bytes":10}},"output":{}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// scrapes right away rather than panicking.
+	snapshots, errs := client.WatchMetrics(ctx, 0)
+	select {
+	case <-snapshots:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+
+	cancel()
+	for range snapshots {
+	}
+	for range errs {
+	}
+}
+
+func TestClient_WatchMetrics_cancelInFlight(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	inFlight := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(inFlight)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &Client{
+		HTTPClient: srv.Client(),
+		BaseURL:    srv.URL,
+	}
+	defer srv.Client().CloseIdleConnections()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	snapshots, errs := client.WatchMetrics(ctx, time.Hour)
+	<-inFlight
+	cancel()
+	waitClosed(t, snapshots, errs)
+}
+
+// waitClosed fails unless both channels get closed promptly
+// without delivering anything else.
+func waitClosed(t *testing.T, snapshots <-chan TimedMetrics, errs <-chan error) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for snapshots != nil || errs != nil {
+		select {
+		case s, ok := <-snapshots:
+			if ok {
+				t.Fatalf("want no snapshot after cancel; got %+v", s)
+			}
+			snapshots = nil
+		case err, ok := <-errs:
+			if ok {
+				t.Fatalf("want no error after cancel; got %v", err)
+			}
+			errs = nil
+		case <-timeout:
+			t.Fatal("want channels closed after cancel")
+		}
+	}
+}